@@ -1,6 +1,8 @@
 package ortfomk
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"io/ioutil"
@@ -8,27 +10,32 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
-	exprVM "github.com/antonmedv/expr/vm"
 	"github.com/stoewer/go-strcase"
 	"gopkg.in/yaml.v3"
-	v8 "rogchap.com/v8go"
 )
 
-var g *GlobalData = &GlobalData{}
-var DynamicPathExpressionsCache = map[string]*exprVM.Program{}
+// g is the default builder, used by the package-level functions (see defaultbuilder.go).
+// It is set by WarmUp and SetGlobalData.
+var g = &Builder{}
 
 type Translations map[string]*TranslationsOneLang
 
-// GlobalData holds data that is used throughout the whole build process
-type GlobalData struct {
+// Builder builds a site: it holds its configuration, database and translations,
+// and the state of its builds (progress, build report, timings, sitemap, etc.).
+// Multiple builders can be used at the same time, each building their own site.
+// The zero value of its unexported fields is ready to use, see NewBuilder.
+type Builder struct {
 	mu sync.Mutex
 
 	Translations Translations
 	Database
 	// Maps each link to the pages in which they appear
-	HTTPLinks         map[string][]string
-	Spinner           Spinner
+	HTTPLinks map[string][]string
+	Spinner   Spinner
+	// What the step started last is about, shown by the spinner and written to the progress file.
+	// It and Progress are guarded by mu: pages being built pass their own ProgressDetails down instead.
 	CurrentObjectID   string
 	CurrentOutputFile string
 	CurrentLanguage   string
@@ -44,29 +51,79 @@ type GlobalData struct {
 	OutputDirectory    string
 	TemplatesDirectory string
 	AdditionalData     map[string]interface{}
+	// Manifest of the current build, used to skip pages that are up to date and to remove stale output files.
+	Manifest *BuildManifest
+	// V8 isolates pages are rendered in, see JSRuntimes
+	jsRuntimes *JSRuntimePool
+
+	sitemap                     sitemapCollector
+	outputClaims                outputClaims
+	buildReport                 buildReport
+	buildFailures               buildFailures
+	timings                     timings
+	progressEvents              progressEvents
+	sharedDataCache             sharedDataCache
+	referencedGlobals           referencedGlobalsCache
+	embeddedPugCompilerInstance embeddedPugCompilerInstance
+	reloader                    liveReloader
+	pageWorkers                 pageWorkers
+	lastBuild                   lastBuild
 }
 
+// GlobalData is the former name of Builder.
+//
+// Deprecated: use Builder.
+type GlobalData = Builder
+
 type Flags struct {
 	ProgressFile string
 	Silent       bool
+	NoCache      bool
+	// ListStale only lists output files left over from previous builds, instead of removing them.
+	ListStale bool
+	// ProgressEvents is where to append progress events (see ProgressEvent), "-" meaning the standard output.
+	ProgressEvents string
+	// AllowCollisions lets pages be built to the same output file, the last one built overwriting the others.
+	AllowCollisions bool
+	// Workers is how many pages are built at the same time. 0 means runtime.NumCPU().
+	Workers int
+}
+
+// NewBuilder creates a builder that builds the templates of templatesDirectory to outputDirectory.
+// Its database, translations and build manifest are set separately, see LoadDatabase, LoadTranslations and LoadBuildManifest.
+func NewBuilder(templatesDirectory string, outputDirectory string, configuration Configuration, flags Flags) *Builder {
+	return &Builder{
+		TemplatesDirectory: templatesDirectory,
+		OutputDirectory:    outputDirectory,
+		Configuration:      configuration,
+		Flags:              flags,
+		HTTPLinks:          make(map[string][]string),
+		Spinner:            DummySpinner{},
+	}
 }
 
 // WarmUp needs to be run before any building starts.
-// It sets the global data, scans the template directory
-// to determine the total number of pages to build, and starts the spinner.
-func WarmUp(data *GlobalData) {
-	g = data
-	g.Spinner = CreateSpinner()
+// It opens the progress events file and starts the spinner.
+func (g *Builder) WarmUp() {
+	if g.Flags.ProgressEvents != "" {
+		if err := g.OpenProgressEvents(g.Flags.ProgressEvents); err != nil {
+			g.LogError("Couldn't open progress events file: %s", err)
+		}
+	}
+	g.Spinner = g.CreateSpinner()
 	g.Spinner.Start()
 }
 
 // CoolDown needs to be stop before the program exits.
 // It properly stops the spinner.
-func CoolDown() {
-	LogDebug("cooling down")
+func (g *Builder) CoolDown() {
+	g.LogDebug("cooling down")
 	g.Spinner.Stop()
 	// make the cursor again, since spinner.Stop() doesn't seem to take care of it.
-	fmt.Printf("\033[?25h")
+	if logFormat != LogFormatJSON {
+		fmt.Printf("\033[?25h")
+	}
+	g.CloseProgressEvents()
 }
 
 func SetGlobalData(data *GlobalData) {
@@ -81,7 +138,11 @@ func SetDatabaseOnGlobalData(database Database) {
 	g.Database = database
 }
 
-func LoadAdditionalData(filesToLoad []string) (additionalData map[string]interface{}, err error) {
+func SetBuildManifestOnGlobalData(manifest *BuildManifest) {
+	g.Manifest = manifest
+}
+
+func (g *Builder) LoadAdditionalData(filesToLoad []string) (additionalData map[string]interface{}, err error) {
 	additionalData = make(map[string]interface{})
 	for _, file := range filesToLoad {
 		var loaded interface{}
@@ -96,7 +157,7 @@ func LoadAdditionalData(filesToLoad []string) (additionalData map[string]interfa
 		}
 
 		if loaded == nil {
-			LogWarning("Loaded data from %s is null", file)
+			g.LogWarning("Loaded data from %s is null", file)
 		}
 
 		additionalData[strcase.LowerCamelCase(filepathStem(file))] = loaded
@@ -104,125 +165,85 @@ func LoadAdditionalData(filesToLoad []string) (additionalData map[string]interfa
 	return additionalData, nil
 }
 
-func ComputeTotalToBuildCount() {
+func (g *Builder) ComputeTotalToBuildCount() {
+	total := g.ToBuildTotalCount(g.TemplatesDirectory)
 	g.mu.Lock()
-	g.Progress.Total = ToBuildTotalCount(g.TemplatesDirectory)
+	g.Progress.Total = total
 	g.mu.Unlock()
 }
 
-func ToBuildTotalCount(in string) (count int) {
-	err := filepath.WalkDir(in, func(path string, entry fs.DirEntry, err error) error {
-		currentDirectory := filepath.Dir(path)
-		if strings.Contains(path, "/mixins/") {
-			return nil
-		}
-		if !(strings.HasSuffix(path, ".pug") || strings.HasSuffix(path, ".html")) {
-			return nil
-		}
-		if err != nil {
-			return err
-		}
-		ortfoignore, err := closestOrtfoignore(currentDirectory)
-		if err != nil {
-			return err
-		}
-		if ortfoignore != nil && ortfoignore.Ignore(path) {
-			LogDebug("ignoring %s because of ortfoignore at %s", path, filepath.Join(ortfoignore.Base(), ".ortfoignore"))
-			return nil
-		}
-		LogDebug("walking into %s", path)
-		// Collect variables the path depends upon
-		pathVariables := make([]string, 0)
-		for _, expr := range DynamicPathExpressions(path) {
-			variables, err := VariablesOfExpression(expr)
-			if err != nil {
-				return fmt.Errorf("couldn't extract variables of expression %q: %w", expr, err)
-			}
-			pathVariables = append(pathVariables, variables...)
-		}
-		pathVariables = deduplicate(pathVariables)
-
-		if len(excluding(pathVariables, "language")) > 0 {
-			for _, variable := range pathVariables {
-				switch variable {
-				case "work":
-					for _, lang := range []string{"fr", "en"} {
-						for _, work := range g.Works {
-							if distPath, err := (&Hydration{language: lang, work: work}).GetDistFilepath(path); distPath != "" && err == nil {
-								count += 1
-							}
-						}
-					}
-				case "tag":
-					for _, lang := range []string{"fr", "en"} {
-						for _, tag := range g.Tags {
-							if distPath, err := (&Hydration{language: lang, tag: tag}).GetDistFilepath(path); distPath != "" && err == nil {
-								count += 1
-							}
-						}
-					}
-				case "technology":
-					for _, lang := range []string{"fr", "en"} {
-						for _, tech := range g.Technologies {
-							if distPath, err := (&Hydration{language: lang, tech: tech}).GetDistFilepath(path); distPath != "" && err == nil {
-								count += 1
-							}
-						}
-					}
-				case "site":
-					for _, lang := range []string{"fr", "en"} {
-						for _, site := range g.Sites {
-							if distPath, err := (&Hydration{language: lang, site: site}).GetDistFilepath(path); distPath != "" && err == nil {
-								count += 1
-							}
-						}
-					}
-				case "collection":
-					for _, lang := range []string{"fr", "en"} {
-						for _, collection := range g.Collections {
-							if distPath, err := (&Hydration{language: lang, collection: collection}).GetDistFilepath(path); distPath != "" && err == nil {
-								count += 1
-							}
-						}
-					}
-				}
-			}
-		} else {
-			for _, lang := range []string{"fr", "en"} {
-				if distPath, err := (&Hydration{language: lang}).GetDistFilepath(path); distPath != "" && err == nil {
-					count += 1
-				}
-			}
-		}
-		LogDebug("count is now %d", count)
-		return nil
-	})
-	if err != nil {
-		LogError("couldn't count the total number of pages to build: %s", err)
+func (g *Builder) ToBuildTotalCount(in string) (count int) {
+	routes, err := g.Routes(in)
+	var routesErr *RoutesError
+	if errors.As(err, &routesErr) {
+		// Building these pages fails too, and reports why
+		g.LogDebug("not counting pages whose output path can't be computed: %s", err)
+	} else if err != nil {
+		g.LogError("couldn't count the total number of pages to build: %s", err)
 	}
-	return
+	return len(routes)
+}
+
+// JSRuntimes returns the pool of JavaScript runtimes pages are rendered in.
+// Unless BuildAll set it, it holds as many runtimes as there are page workers (see Flags.Workers).
+func (g *Builder) JSRuntimes() *JSRuntimePool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.jsRuntimes == nil {
+		g.jsRuntimes = NewJSRuntimePool(g.workersCount())
+	}
+	return g.jsRuntimes
 }
 
-// BuildAll builds pages from templates found in the given directory in parallel, using the given number of goroutines (workersCount).
-// if workersCount is 0 or less, it is set to the number of page templates to compile.
-func BuildAll(in string, workersCount int) (built []string, httpLinks map[string][]string, err error) {
+// SetJSRuntimes replaces the pool of JavaScript runtimes, disposing of the previous one.
+func (g *Builder) SetJSRuntimes(pool *JSRuntimePool) {
+	g.mu.Lock()
+	previous := g.jsRuntimes
+	g.jsRuntimes = pool
+	g.mu.Unlock()
+	if previous != nil {
+		previous.Dispose()
+	}
+}
+
+// BuildAll builds pages from templates found in the given directory in parallel, building up to workersCount pages at the same time.
+// If workersCount is 0 or less, Flags.Workers is used, and runtime.NumCPU() if that is not set either.
+// Pages of a same template are built in parallel too (see pageGroup).
+//
+// Once ctx is cancelled, no new page is started: pages being built are finished, and a *BuildInterruptedError is returned.
+// The sitemap, feeds and search indexes are not written, and outputs of the previous build are not removed as stale.
+//
+// The build report (see CurrentBuildReport) only holds what went wrong during the last call to BuildAll.
+func (g *Builder) BuildAll(ctx context.Context, in string, workersCount int) (built []string, httpLinks map[string][]string, err error) {
 	toBuildChannel := make(chan string)
 	httpLinks = g.HTTPLinks
-	LogDebug("scanning for things to build")
-	toBuild, err := ScanAll(in)
+	startedAt := time.Now()
+	if g.Manifest != nil {
+		g.Manifest.StartBuild()
+	}
+	g.sitemap.reset()
+	g.resetBuildReport()
+	g.resetOutputClaims()
+	g.resetTimings()
+	g.resetSharedData()
+	g.resetReferencedGlobals()
+	g.LogDebug("scanning for things to build")
+	toBuild, err := g.ScanAll(in)
 	if err != nil {
 		return built, httpLinks, fmt.Errorf("while scanning templates directory: %w", err)
 	}
 
 	if workersCount <= 0 {
-		workersCount = len(toBuild)
+		workersCount = g.workersCount()
 	}
+	g.SetJSRuntimes(NewJSRuntimePool(workersCount))
+	g.setPageWorkers(workersCount)
 
 	var builtMutex sync.Mutex
 	var wg sync.WaitGroup
 	wg.Add(workersCount)
 
-	LogDebug("launching 5 parallel build subroutines")
+	g.LogDebug("launching %d parallel build subroutines", workersCount)
 	for i := 0; i < workersCount; i++ {
 		go func(toBuildChannel chan string) {
 			for {
@@ -239,8 +260,8 @@ func BuildAll(in string, workersCount int) (built []string, httpLinks map[string
 				for _, expr := range DynamicPathExpressions(path) {
 					variables, err := VariablesOfExpression(expr)
 					if err != nil {
-						LogError("couldn't extract variables of expression %q: %s", expr, err)
-						return
+						g.LogError("couldn't extract variables of expression %q: %s", expr, err)
+						continue
 					}
 					pathVariables = append(pathVariables, variables...)
 				}
@@ -250,19 +271,19 @@ func BuildAll(in string, workersCount int) (built []string, httpLinks map[string
 					for _, variable := range pathVariables {
 						switch variable {
 						case "work":
-							newlyBuilt = append(newlyBuilt, BuildWorkPages(path)...)
+							newlyBuilt = append(newlyBuilt, g.BuildWorkPages(ctx, path)...)
 						case "tag":
-							newlyBuilt = append(newlyBuilt, BuildTagPages(path)...)
+							newlyBuilt = append(newlyBuilt, g.BuildTagPages(ctx, path)...)
 						case "technology":
-							newlyBuilt = append(newlyBuilt, BuildTechPages(path)...)
+							newlyBuilt = append(newlyBuilt, g.BuildTechPages(ctx, path)...)
 						case "site":
-							newlyBuilt = append(newlyBuilt, BuildSitePages(path)...)
+							newlyBuilt = append(newlyBuilt, g.BuildSitePages(ctx, path)...)
 						case "collection":
-							newlyBuilt = append(newlyBuilt, BuildCollectionPages(path)...)
+							newlyBuilt = append(newlyBuilt, g.BuildCollectionPages(ctx, path)...)
 						}
 					}
 				} else {
-					newlyBuilt = append(newlyBuilt, BuildRegularPage(path)...)
+					newlyBuilt = append(newlyBuilt, g.BuildRegularPage(ctx, path)...)
 				}
 
 				builtMutex.Lock()
@@ -272,17 +293,103 @@ func BuildAll(in string, workersCount int) (built []string, httpLinks map[string
 		}(toBuildChannel)
 	}
 
-	LogDebug("starting to fill toBuild channel")
+	g.LogDebug("starting to fill toBuild channel")
+dispatch:
 	for _, path := range toBuild {
-		toBuildChannel <- path
+		select {
+		case toBuildChannel <- path:
+		case <-ctx.Done():
+			break dispatch
+		}
 	}
 	close(toBuildChannel)
 	wg.Wait()
+
+	if ctx.Err() != nil {
+		return built, httpLinks, g.interruptBuild(ctx, in, built, startedAt)
+	}
+
+	sitemapFiles, sitemapErr := g.WriteSitemap()
+	if sitemapErr != nil {
+		g.LogError("couldn't write the sitemap: %s", sitemapErr)
+	}
+	feedFiles, feedsErr := g.WriteFeeds()
+	if feedsErr != nil {
+		g.LogError("couldn't write the feeds: %s", feedsErr)
+	}
+	searchIndexFiles, searchErr := g.WriteSearchIndexes()
+	if searchErr != nil {
+		g.LogError("couldn't write the search indexes: %s", searchErr)
+	}
+	if g.Manifest != nil {
+		for _, file := range append(append(sitemapFiles, feedFiles...), searchIndexFiles...) {
+			g.Manifest.RecordOutput(file)
+		}
+	}
+
+	totals := g.recordLastBuild(built, startedAt, nil)
+	if g.Manifest != nil {
+		g.LogInfo("Built %d pages, skipped %d pages that were up to date", totals.Built, totals.Skipped)
+		g.RemoveStaleOutputs(g.Manifest, g.Flags.ListStale)
+		g.saveBuildManifest()
+	}
+
+	if collisions := g.OutputPathCollisions(); len(collisions) > 0 && !g.Flags.AllowCollisions {
+		return built, httpLinks, fmt.Errorf("%d pages are built to an output file another page is also built to (use --allow-collisions to ignore)", len(collisions))
+	}
 	return
 }
 
+// BuildInterruptedError is returned by BuildAll when its context is cancelled before every page was built.
+type BuildInterruptedError struct {
+	// Output files of the pages built before the build stopped
+	Built []string
+	// Output files of the pages that were not built
+	NotBuilt []string
+	// Why the build was interrupted, i.e. the context's error
+	Cause error
+}
+
+func (e *BuildInterruptedError) Error() string {
+	return fmt.Sprintf("build interrupted (%s): %d pages were built, %d were not", e.Cause, len(e.Built), len(e.NotBuilt))
+}
+
+func (e *BuildInterruptedError) Unwrap() error {
+	return e.Cause
+}
+
+// interruptBuild wraps up a build that was interrupted after building the given pages, and returns the error BuildAll returns.
+// The manifest keeps the previous build's entries for pages that were not built, so that they are not considered stale by the next build.
+func (g *Builder) interruptBuild(ctx context.Context, in string, built []string, startedAt time.Time) error {
+	interruption := &BuildInterruptedError{Built: built, NotBuilt: make([]string, 0), Cause: ctx.Err()}
+	wasBuilt := make(map[string]bool, len(built))
+	for _, outPath := range built {
+		wasBuilt[outPath] = true
+	}
+	routes, err := g.Routes(in)
+	var routesErr *RoutesError
+	if err != nil && !errors.As(err, &routesErr) {
+		g.LogError("couldn't list the pages that were not built: %s", err)
+	}
+	for _, route := range routes {
+		if !wasBuilt[route.OutPath] {
+			wasBuilt[route.OutPath] = true
+			interruption.NotBuilt = append(interruption.NotBuilt, route.OutPath)
+		}
+	}
+
+	if g.Manifest != nil {
+		for _, outPath := range g.Manifest.StaleOutputs() {
+			g.Manifest.KeepPrevious(outPath)
+		}
+		g.saveBuildManifest()
+	}
+	g.recordLastBuild(built, startedAt, interruption)
+	return interruption
+}
+
 // ScanAll scans the given directory for paths to build, recursively.
-func ScanAll(in string) (toBuild []string, err error) {
+func (g *Builder) ScanAll(in string) (toBuild []string, err error) {
 	err = filepath.WalkDir(in, func(path string, entry fs.DirEntry, err error) error {
 		// LogDebug("Walking into %s", path)
 		currentDirectory := filepath.Dir(path)
@@ -295,12 +402,12 @@ func ScanAll(in string) (toBuild []string, err error) {
 		if err != nil {
 			return err
 		}
-		ortfoignore, err := closestOrtfoignore(currentDirectory)
+		ortfoignore, err := g.closestOrtfoignore(currentDirectory)
 		if err != nil {
 			return err
 		}
 		if ortfoignore != nil && ortfoignore.Ignore(path) {
-			LogDebug("ignoring %s because of ortfoignore at %s", path, filepath.Join(ortfoignore.Base(), ".ortfoignore"))
+			g.LogDebug("ignoring %s because of ortfoignore at %s", path, filepath.Join(ortfoignore.Base(), ".ortfoignore"))
 			return nil
 		}
 
@@ -311,149 +418,174 @@ func ScanAll(in string) (toBuild []string, err error) {
 }
 
 // BuildTechPages builds all technology pages using `using`
-func BuildTechPages(using string) (built []string) {
+func (g *Builder) BuildTechPages(ctx context.Context, using string) (built []string) {
 	templateContent, err := os.ReadFile(using)
 	if err != nil {
-		LogError("couldn't read the template: %s", err)
+		g.LogError("couldn't read the template: %s", err)
+		g.keepPreviousOutputsOf(using)
 		return
 	}
-	javascriptRuntime := v8.NewIsolate()
-	compiledTemplate, err := CompileTemplate(using, templateContent)
+	compiledTemplate, err := g.CompileTemplate(using, templateContent)
 	if err != nil {
-		LogError("could build technology pages’ template: %s", err)
+		g.LogError("could build technology pages’ template: %s", err)
+		g.recordCompileFailure(using, err)
+		g.keepPreviousOutputsOf(using)
 		return
 	}
+	pages := g.newPageGroup(ctx, using, compiledTemplate)
 	for _, tech := range g.Technologies {
-		SetCurrentObjectID(tech.URLName)
-		built = append(built, BuildPage(javascriptRuntime, using, compiledTemplate, &Hydration{tech: tech})...)
-		SetCurrentObjectID("")
+		if !pages.Build(&Hydration{tech: tech}) {
+			break
+		}
 	}
-	javascriptRuntime.Dispose()
-	return
+	return pages.Wait()
 }
 
 // BuildSitePages builds all site pages using the template at the given filename
-func BuildSitePages(using string) (built []string) {
+func (g *Builder) BuildSitePages(ctx context.Context, using string) (built []string) {
 	templateContent, err := os.ReadFile(using)
 	if err != nil {
-		LogError("couldn't read the template: %s", err)
+		g.LogError("couldn't read the template: %s", err)
+		g.keepPreviousOutputsOf(using)
 		return
 	}
 
-	javascriptRuntime := v8.NewIsolate()
-	compiledTemplate, err := CompileTemplate(using, templateContent)
+	compiledTemplate, err := g.CompileTemplate(using, templateContent)
 	if err != nil {
-		LogError("could build site pages’ template: %s", err)
+		g.LogError("could build site pages’ template: %s", err)
+		g.recordCompileFailure(using, err)
+		g.keepPreviousOutputsOf(using)
 		return
 	}
+	pages := g.newPageGroup(ctx, using, compiledTemplate)
 	for _, site := range g.Sites {
-		SetCurrentObjectID(site.Name)
-		built = append(built, BuildPage(javascriptRuntime, using, compiledTemplate, &Hydration{site: site})...)
-		SetCurrentObjectID("")
+		if !pages.Build(&Hydration{site: site}) {
+			break
+		}
 	}
-	javascriptRuntime.Dispose()
-	return
+	return pages.Wait()
 }
 
 // BuildTagPages builds all tag pages using the given filename
-func BuildTagPages(using string) (built []string) {
+func (g *Builder) BuildTagPages(ctx context.Context, using string) (built []string) {
 	templateContent, err := os.ReadFile(using)
 	if err != nil {
-		LogError("couldn't read the template: %s", err)
+		g.LogError("couldn't read the template: %s", err)
+		g.keepPreviousOutputsOf(using)
 		return
 	}
 
-	javascriptRuntime := v8.NewIsolate()
-	compiledTemplate, err := CompileTemplate(using, templateContent)
+	compiledTemplate, err := g.CompileTemplate(using, templateContent)
 	if err != nil {
-		LogError("could build tag pages’ template: %s", err)
+		g.LogError("could build tag pages’ template: %s", err)
+		g.recordCompileFailure(using, err)
+		g.keepPreviousOutputsOf(using)
 		return
 	}
+	pages := g.newPageGroup(ctx, using, compiledTemplate)
 	for _, tag := range g.Tags {
-		SetCurrentObjectID(tag.Singular)
-		built = append(built, BuildPage(javascriptRuntime, using, compiledTemplate, &Hydration{tag: tag})...)
-		SetCurrentObjectID("")
+		if !pages.Build(&Hydration{tag: tag}) {
+			break
+		}
 	}
-	javascriptRuntime.Dispose()
-	return
+	return pages.Wait()
 }
 
 // BuildCollectionPages builds all collection pages using the given filename
-func BuildCollectionPages(using string) (built []string) {
+func (g *Builder) BuildCollectionPages(ctx context.Context, using string) (built []string) {
 	templateContent, err := os.ReadFile(using)
 	if err != nil {
-		LogError("couldn't read the template: %s", err)
+		g.LogError("couldn't read the template: %s", err)
+		g.keepPreviousOutputsOf(using)
 		return
 	}
 
-	javascriptRuntime := v8.NewIsolate()
-	compiledTemplate, err := CompileTemplate(using, templateContent)
+	compiledTemplate, err := g.CompileTemplate(using, templateContent)
 	if err != nil {
-		LogError("could build tag pages’ template: %s", err)
+		g.LogError("could build tag pages’ template: %s", err)
+		g.recordCompileFailure(using, err)
+		g.keepPreviousOutputsOf(using)
 		return
 	}
+	pages := g.newPageGroup(ctx, using, compiledTemplate)
 	for _, collection := range g.Collections {
-		SetCurrentObjectID(collection.ID)
-		built = append(built, BuildPage(javascriptRuntime, using, compiledTemplate, &Hydration{collection: collection})...)
-		SetCurrentObjectID("")
+		if !pages.Build(&Hydration{collection: collection}) {
+			break
+		}
 	}
-	javascriptRuntime.Dispose()
-	return
+	return pages.Wait()
 }
 
 // BuildWorkPages builds all work pages using the given filepath
-func BuildWorkPages(using string) (built []string) {
+func (g *Builder) BuildWorkPages(ctx context.Context, using string) (built []string) {
 	templateContent, err := os.ReadFile(using)
 	if err != nil {
-		LogError("coudln't read template: %s", err)
+		g.LogError("coudln't read template: %s", err)
+		g.keepPreviousOutputsOf(using)
+		return
 	}
 
-	javascriptRuntime := v8.NewIsolate()
-	compiledTemplate, err := CompileTemplate(using, templateContent)
+	compiledTemplate, err := g.CompileTemplate(using, templateContent)
 	if err != nil {
-		LogError("couldn't build work pages’ template: %s", err)
+		g.LogError("couldn't build work pages’ template: %s", err)
+		g.recordCompileFailure(using, err)
+		g.keepPreviousOutputsOf(using)
 		return
 	}
+	pages := g.newPageGroup(ctx, using, compiledTemplate)
 	for _, work := range g.Works {
-		SetCurrentObjectID(work.ID)
-		built = append(built, BuildPage(javascriptRuntime, using, compiledTemplate, &Hydration{work: work})...)
-		SetCurrentObjectID("")
+		if !pages.Build(&Hydration{work: work}) {
+			break
+		}
 	}
-	javascriptRuntime.Dispose()
-	return
+	return pages.Wait()
 }
 
 // BuildRegularPage builds a given page that isn't dynamic (i.e. does not require object data,
 // as opposed to work, tag and tech pages)
-func BuildRegularPage(path string) (built []string) {
-	SetCurrentObjectID(strings.TrimSuffix(filepath.Base(g.Progress.File), filepath.Ext(g.Progress.File)))
+func (g *Builder) BuildRegularPage(ctx context.Context, path string) (built []string) {
 	templateContent, err := os.ReadFile(path)
 	if err != nil {
-		LogError("couldn't read the template: %s", err)
+		g.LogError("couldn't read the template: %s", err)
+		g.keepPreviousOutputsOf(path)
 		return
 	}
 
-	javascriptRuntime := v8.NewIsolate()
-	compiledTemplate, err := CompileTemplate(path, templateContent)
+	compiledTemplate, err := g.CompileTemplate(path, templateContent)
 	if err != nil {
-		LogError("could not build the page’s template: %s", err)
+		g.LogError("could not build the page’s template: %s", err)
+		g.recordCompileFailure(path, err)
+		g.keepPreviousOutputsOf(path)
 		return
 	}
-	LogDebug("finished compiling")
+	g.LogDebug("finished compiling")
 
-	built = BuildPage(javascriptRuntime, path, compiledTemplate, &Hydration{})
-	javascriptRuntime.Dispose()
-	return built
+	pages := g.newPageGroup(ctx, path, compiledTemplate)
+	pages.Build(&Hydration{})
+	return pages.Wait()
 }
 
-// BuildPage builds a single page
-func BuildPage(javascriptRuntime *v8.Isolate, pageName string, compiledTemplate []byte, hydration *Hydration) (built []string) {
+// BuildPage builds a single page.
+// When incremental builds are enabled (see BuildManifest), pages whose inputs did not change since the last build are skipped.
+// Once ctx is cancelled, the page is not built in the remaining languages.
+// Pages are built concurrently: what is being built is passed down as a ProgressDetails value, and not read from the builder.
+func (g *Builder) BuildPage(ctx context.Context, javascriptRuntime *JSRuntime, pageName string, compiledTemplate []byte, hydration *Hydration) (built []string) {
+	objectID := hydration.ObjectID()
+	if objectID == "" {
+		objectID = strings.TrimSuffix(filepath.Base(pageName), filepath.Ext(pageName))
+	}
 	// Add additional data to hydration
-	for _, language := range []string{"fr", "en"} {
+	for _, language := range g.Configuration.Languages {
+		if ctx.Err() != nil {
+			return
+		}
 		hydration.language = language
-		outPath, err := hydration.GetDistFilepath(pageName)
+		page := ProgressDetails{ObjectID: objectID, File: pageName, Language: language}
+		outPath, err := g.GetDistFilepath(hydration, pageName)
 		if err != nil {
-			LogError("Invalid path: %s", err)
+			g.logPageError(page, "Invalid path: %s", err)
+			// The output file is not known, keep all of the template's previous output files instead
+			g.keepPreviousOutputsOf(pageName)
 			continue
 		}
 		if outPath == "" {
@@ -461,45 +593,143 @@ func BuildPage(javascriptRuntime *v8.Isolate, pageName string, compiledTemplate
 			continue
 		}
 
-		Status(StepBuildPage, ProgressDetails{
-			File:     pageName,
-			Language: language,
-			OutFile:  outPath,
-		})
-		content, err := RunTemplate(
-			javascriptRuntime,
-			hydration,
-			pageName,
-			compiledTemplate,
-		)
+		page.OutFile = outPath
+		startedAt := time.Now()
+		g.Status(StepBuildPage, page)
+		g.ClaimOutputPath(outPath, pageName, hydration)
+		phaseStartedAt := time.Now()
+		compiledJSFile, err := g.GenerateJSFile(hydration, pageName, string(compiledTemplate))
+		g.recordPageTiming(pageName, outPath, PhaseGenerate, phaseStartedAt)
 		if err != nil {
-			// PrintTemplateErrorMessage("executing template", NameOfTemplate(pageName, *hydration), string(compiledTemplate), err, "js")
-			LogError("couldn't execute template %s with %s: %s", pageName, hydration.Name(), err)
+			g.logPageError(page, "couldn't generate template %s with %s: %s", pageName, hydration.Name(), err)
+			g.RecordBuildFailure(outPath, g.GetPathRelativeToSrcDir(pageName), hydration, fmt.Errorf("while generating template: %w", err))
+			g.keepPreviousOutput(outPath)
 			continue
 		}
-		content = g.Translations[language].TranslateHydrated(content)
-		g.mu.Lock()
-		for _, link_ := range AllLinks(content).ToSlice() {
-			link := link_.(string)
-			if _, exists := g.HTTPLinks[link]; exists {
-				g.HTTPLinks[link] = append(g.HTTPLinks[link], outPath)
+
+		inputs := g.PageInputs(compiledJSFile)
+		inputs.Source = g.GetPathRelativeToSrcDir(pageName)
+		if g.Manifest != nil && !g.Flags.NoCache {
+			if entry, upToDate := g.Manifest.UpToDate(outPath, inputs, g.Translations[language]); upToDate {
+				g.LogDebug("%s is up to date, not re-building it", outPath)
+				g.Translations[language].Replay(entry.Translations)
+				g.recordLinks(outPath, entry.Links)
+				g.Manifest.Record(outPath, entry, true)
+				g.addToSitemap(pageName, hydration, outPath)
+				g.ClearBuildFailure(outPath)
+				built = append(built, outPath)
+				if err := g.IncrementProgress(); err != nil {
+					g.logPageError(page, "couldn't write progress to file: %s", err)
+				}
+				g.progressPageBuiltEvent(page, time.Since(startedAt), true)
+				continue
 			}
-			g.HTTPLinks[link] = []string{outPath}
 		}
-		g.mu.Unlock()
+
+		phaseStartedAt = time.Now()
+		content, err := RunJSFile(javascriptRuntime, compiledJSFile, pageName, hydration)
+		g.recordPageTiming(pageName, outPath, PhaseRun, phaseStartedAt)
+		if err != nil {
+			// PrintTemplateErrorMessage("executing template", NameOfTemplate(pageName, *hydration), string(compiledTemplate), err, "js")
+			g.logPageError(page, "couldn't execute template %s with %s: %s", pageName, hydration.Name(), err)
+			g.RecordBuildFailure(outPath, g.GetPathRelativeToSrcDir(pageName), hydration, err)
+			g.keepPreviousOutput(outPath)
+			continue
+		}
+		phaseStartedAt = time.Now()
+		content, translationUsage := g.TranslateHydratedPage(language, content)
+		g.recordPageTiming(pageName, outPath, PhaseTranslate, phaseStartedAt)
+		links := make([]string, 0)
+		for _, link := range AllLinks(content).ToSlice() {
+			links = append(links, link.(string))
+		}
+		g.recordLinks(outPath, links)
 		os.MkdirAll(filepath.Dir(outPath), 0777)
-		LogDebug("outputting to %s", outPath)
+		g.LogDebug("outputting to %s", outPath)
+		// Files are written atomically, so that interrupting the build never leaves truncated pages behind
 		if strings.HasSuffix(outPath, ".pdf") {
-			WritePDF(content, outPath)
-			ioutil.WriteFile(strings.TrimSuffix(outPath, ".pdf")+".html", []byte(content), 0777)
+			g.Status(StepGeneratePDF, page)
+			phaseStartedAt = time.Now()
+			err = g.WritePDF(content, outPath)
+			g.recordPageTiming(pageName, outPath, PhasePDF, phaseStartedAt)
+			if err == nil {
+				err = writeFileAtomically(strings.TrimSuffix(outPath, ".pdf")+".html", []byte(content), 0o644)
+			}
 		} else {
-			ioutil.WriteFile(outPath, []byte(content), 0777)
+			err = writeFileAtomically(outPath, []byte(content), 0o644)
+		}
+		if err != nil {
+			g.logPageError(page, "couldn't write %s: %s", outPath, err)
+			g.RecordBuildFailure(outPath, g.GetPathRelativeToSrcDir(pageName), hydration, fmt.Errorf("while writing the page: %w", err))
+			g.keepPreviousOutput(outPath)
+			continue
 		}
+		g.ClearBuildFailure(outPath)
+		if g.Manifest != nil {
+			inputs.Translations = translationUsage
+			inputs.TranslationsFingerprint = g.Translations[language].FingerprintOf(translationUsage)
+			inputs.Links = links
+			g.Manifest.Record(outPath, inputs, false)
+		}
+		g.addToSitemap(pageName, hydration, outPath)
 		built = append(built, outPath)
-		progressWriteErr := IncrementProgress()
+		progressWriteErr := g.IncrementProgress()
 		if progressWriteErr != nil {
-			LogError("couldn't write progress to file: %s", progressWriteErr)
+			g.logPageError(page, "couldn't write progress to file: %s", progressWriteErr)
 		}
+		g.progressPageBuiltEvent(page, time.Since(startedAt), false)
 	}
 	return
 }
+
+// recordLinks remembers that the page at outPath contains the given HTTP links, to later check them for dead links.
+func (g *Builder) recordLinks(outPath string, links []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, link := range links {
+		g.HTTPLinks[link] = append(g.HTTPLinks[link], outPath)
+	}
+}
+
+// keepPreviousOutput makes sure the output of a page that failed to build is not removed as stale.
+func (g *Builder) keepPreviousOutput(outPath string) {
+	if g.Manifest != nil {
+		g.Manifest.KeepPrevious(outPath)
+	}
+}
+
+// keepPreviousOutputsOf makes sure the outputs of a template that failed to build are not removed as stale.
+func (g *Builder) keepPreviousOutputsOf(template string) {
+	if g.Manifest != nil {
+		g.Manifest.KeepPreviousOf(g.GetPathRelativeToSrcDir(template))
+	}
+}
+
+// recordCompileFailure records a build failure (see RecordBuildFailure) for every page of a template that could not be compiled.
+func (g *Builder) recordCompileFailure(template string, err error) {
+	if os.Getenv("ENV") != "dev" {
+		return
+	}
+	hydrations, hydrationsErr := g.HydrationsOf(template)
+	if hydrationsErr != nil {
+		g.LogDebug("not recording compile failure of %s: %s", template, hydrationsErr)
+		return
+	}
+	for _, hydration := range hydrations {
+		outPath, pathErr := g.GetDistFilepath(hydration, template)
+		if pathErr != nil || outPath == "" {
+			continue
+		}
+		g.RecordBuildFailure(outPath, g.GetPathRelativeToSrcDir(template), hydration, fmt.Errorf("while compiling the template: %w", err))
+	}
+}
+
+// saveBuildManifest writes the build manifest to the cache directory, if incremental builds are enabled.
+func (g *Builder) saveBuildManifest() {
+	if g.Manifest == nil {
+		return
+	}
+	if err := g.Manifest.Save(g.BuildManifestPath(g.OutputDirectory)); err != nil {
+		g.LogWarning("couldn't save the build manifest: %s", err)
+	}
+}
@@ -0,0 +1,160 @@
+package ortfomk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set"
+	ortfodb "github.com/ortfo/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestBuilder creates a builder for a site with a single index page, titled title.
+// The page's compiled template is put in the builder's cache, so that building it does not need a pug compiler.
+func newTestBuilder(t *testing.T, title string) *Builder {
+	root := t.TempDir()
+	config := DefaultConfiguration()
+	config.Languages = []string{"en"}
+	config.CacheDirectory = filepath.Join(root, "cache")
+	builder := NewBuilder(filepath.Join(root, "src"), filepath.Join(root, "dist"), config, Flags{})
+	builder.AdditionalData = map[string]interface{}{"site": map[string]string{"title": title}}
+	builder.Translations = Translations{"en": {language: "en", seenMessages: mapset.NewSet()}}
+
+	addTestTemplate(t, builder, "index.pug", "h1= site.title", `function template(locals) { return "<h1>" + locals.site.title + "</h1>" }`)
+	return builder
+}
+
+// addTestTemplate writes the template called name, and puts compiled in the builder's cache as its compiled version.
+func addTestTemplate(t *testing.T, builder *Builder, name string, content string, compiled string) {
+	path := filepath.Join(builder.TemplatesDirectory, name)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	key, err := builder.TemplateCacheKey(path, []byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, writeFileAtomically(filepath.Join(builder.Configuration.CacheDirectory, "templates", key+".js"), []byte(compiled), 0o644))
+}
+
+func TestBuildersAreIndependent(t *testing.T) {
+	builders := []*Builder{newTestBuilder(t, "first"), newTestBuilder(t, "second")}
+
+	var wg sync.WaitGroup
+	for _, builder := range builders {
+		wg.Add(1)
+		go func(builder *Builder) {
+			defer wg.Done()
+			_, _, err := builder.BuildAll(context.Background(), builder.TemplatesDirectory, 0)
+			assert.NoError(t, err)
+		}(builder)
+	}
+	wg.Wait()
+
+	for i, title := range []string{"first", "second"} {
+		content, err := os.ReadFile(filepath.Join(builders[i].OutputDirectory, "index.html"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "<h1>"+title+"</h1>")
+		assert.Empty(t, builders[i].CurrentBuildReport().Errors)
+		assert.Len(t, builders[i].CurrentTimingReport().Pages, 1)
+	}
+}
+
+func TestBuildAllStopsWhenCancelled(t *testing.T) {
+	builder := newTestBuilder(t, "cancelled")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	built, _, err := builder.BuildAll(ctx, builder.TemplatesDirectory, 0)
+	var interruption *BuildInterruptedError
+	assert.ErrorAs(t, err, &interruption)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, built)
+	assert.Equal(t, []string{filepath.Join(builder.OutputDirectory, "index.html")}, interruption.NotBuilt)
+	assert.NoFileExists(t, filepath.Join(builder.OutputDirectory, "index.html"))
+	assert.NoFileExists(t, filepath.Join(builder.OutputDirectory, "sitemap.xml"))
+}
+
+func TestBuildAllBuildsPagesOfATemplateInParallel(t *testing.T) {
+	builder := newTestBuilder(t, "works")
+	builder.Flags.Workers = 2
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		builder.Works = append(builder.Works, Work{Work: ortfodb.Work{ID: id}})
+	}
+	addTestTemplate(t, builder, ":work.pug", "p= CurrentWork.ID", `function template(locals) { return "<p>" + CurrentWork.ID + "</p>" }`)
+
+	built, _, err := builder.BuildAll(context.Background(), builder.TemplatesDirectory, 0)
+	assert.NoError(t, err)
+	assert.Len(t, built, 6)
+	for _, work := range builder.Works {
+		content, err := os.ReadFile(filepath.Join(builder.OutputDirectory, work.ID+".html"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "<p>"+work.ID+"</p>")
+	}
+	assert.Empty(t, builder.CurrentBuildReport().Errors)
+}
+
+// TestBuildAllInParallel builds pages of multiple templates, in multiple languages, at the same time.
+// Run it with -race to check that building pages concurrently is race-free.
+func TestBuildAllInParallel(t *testing.T) {
+	builder := newTestBuilder(t, "parallel")
+	builder.Configuration.Languages = []string{"en", "fr"}
+	builder.Configuration.SourceLanguage = "en"
+	builder.Translations["fr"] = &TranslationsOneLang{language: "fr", seenMessages: mapset.NewSet()}
+	builder.Flags.Workers = 4
+	for _, id := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		builder.Works = append(builder.Works, Work{Work: ortfodb.Work{ID: id}})
+	}
+	assert.NoError(t, os.Remove(filepath.Join(builder.TemplatesDirectory, "index.pug")))
+	addTestTemplate(t, builder, filepath.Join(":language", ":work.pug"), "p(i18n)= CurrentWork.ID", `function template(locals) { return "<p i18n>" + CurrentWork.ID + "</p>" }`)
+	addTestTemplate(t, builder, filepath.Join(":language", "about.pug"), "p(i18n) About", `function template(locals) { return "<p i18n>About</p>" }`)
+
+	built, _, err := builder.BuildAll(context.Background(), builder.TemplatesDirectory, 0)
+	assert.NoError(t, err)
+	assert.Len(t, built, 2*(len(builder.Works)+1))
+	assert.Empty(t, builder.CurrentBuildReport().Errors)
+	assert.Len(t, builder.Translations["fr"].MissingMessages(), len(builder.Works)+1)
+	assert.Equal(t, len(built), builder.ProgressFileData().Processed)
+}
+
+func TestBuildAllKeepsOutputsOfTemplatesThatFailToCompile(t *testing.T) {
+	builder := newTestBuilder(t, "kept")
+	builder.Manifest = NewBuildManifest()
+	built, _, err := builder.BuildAll(context.Background(), builder.TemplatesDirectory, 0)
+	assert.NoError(t, err)
+	assert.Len(t, built, 1)
+
+	// Not in the compiled templates cache, and the compiler is unknown: compiling fails.
+	assert.NoError(t, os.WriteFile(filepath.Join(builder.TemplatesDirectory, "index.pug"), []byte("h1= site.name"), 0o644))
+	builder.Configuration.PugCompiler = "unknown"
+	index := filepath.Join(builder.OutputDirectory, "index.html")
+	built, _, _ = builder.BuildAll(context.Background(), builder.TemplatesDirectory, 0)
+	assert.Empty(t, built)
+	assert.FileExists(t, index)
+	assert.Contains(t, builder.Manifest.Pages, index)
+}
+
+func TestBuildAllResetsTheBuildReport(t *testing.T) {
+	builder := newTestBuilder(t, "reset")
+	builder.LogError("from a previous build")
+	assert.NotEmpty(t, builder.CurrentBuildReport().Errors)
+
+	_, _, err := builder.BuildAll(context.Background(), builder.TemplatesDirectory, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, builder.CurrentBuildReport().Errors)
+}
+
+func TestBuildAllRecordsCompileFailures(t *testing.T) {
+	t.Setenv("ENV", "dev")
+	builder := newTestBuilder(t, "compile failure")
+	// Not in the compiled templates cache, and the compiler is unknown: compiling fails.
+	assert.NoError(t, os.WriteFile(filepath.Join(builder.TemplatesDirectory, "index.pug"), []byte("h1= site.name"), 0o644))
+	builder.Configuration.PugCompiler = "unknown"
+	_, _, err := builder.BuildAll(context.Background(), builder.TemplatesDirectory, 0)
+	assert.NoError(t, err)
+
+	failure, failed := builder.BuildFailureOf(filepath.Join(builder.OutputDirectory, "index.html"))
+	assert.True(t, failed)
+	assert.Contains(t, failure.Message, "while compiling the template")
+	assert.Contains(t, failure.Message, `unknown pug compiler "unknown"`)
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 
 	"github.com/docopt/docopt-go"
@@ -17,25 +20,62 @@ import (
 const CLIUsage = `
 Usage:
 	ortfomk (build|develop) <templates> with <database> to <destination> [--load=<filepath>]... [options]
+	ortfomk routes <templates> with <database> [--json] [options]
 
 Commands:
 	build            Build the website
 	develop          Watch for changes and re-build automatically
+	routes           List every page that would be built: its template, hydration and output file.
+	                 Output files that several pages would be built to are flagged as collisions.
 
 Arguments:
-	<database>       Path to the database JSON file
+	<database>       Path to the database directory, or to the database JSON file inside of it.
+	                 Other database directories to merge with this one can be specified
+	                 in the configuration file, under database.roots.
 	<templates>      Path to the directory containing .pug or .html template files
 	<destination>    Path to the output directory, where the site will be built.
 
 Options:
 	--write-progress=<filepath>   Write current build progress to <filepath>
+	--progress-events=<filepath>  Append build events to <filepath> as newline-delimited JSON, - for stdout.
+	                              See Build Progress.
 	--silent                      Don't output progress status to console
+	--log-format=<format>         Format of log lines: text, or json for one JSON object per line
+	                              with the level, message, timestamp and what is being built.
+	                              The progress spinner is disabled with json. [default: text]
 	--clean					      Clean the output directory before building
+	--atomic                      With build, build into <destination>.staging, and replace <destination> with it
+	                              only once the build succeeded, keeping the previous build in <destination>.previous.
+	                              See Atomic Builds.
+	--no-cache                    Don't use nor update the cache of compiled templates,
+	                              and re-build every page, even those that are up to date
+	--list-stale                  List files produced by a previous build but not by this one,
+	                              instead of removing them
+	--json                        With routes, output JSON instead of a table
+	--allow-collisions            Don't fail when multiple pages are built to the same output file
+	--workers=<count>             Number of pages to build at the same time. Defaults to the number of CPUs.
+	--link-check-workers=<count>  Number of links to check for dead links at the same time [default: 8]
+	--timings                     Print how long each phase of the build took, with the slowest templates and pages
+	--write-timings=<filepath>    Write how long each phase of each page took to <filepath>,
+	                              as CSV if it ends with .csv, as JSON otherwise. Durations are in milliseconds.
+	--fail-on=<reasons>           Comma-separated list of what makes the build fail:
+	                              error, warning, deadlink or missing-translation [default: error]
+	                              Errors always make the build fail, the other reasons are added to them.
 	--load=<filepath>             Path to a JSON or YAML file containing additional data which
 							      will be available to templates as objects (or arrays) whose names will
 								  be the files', but without the extension, and turned into camelCase
 								  (e.g. "my-data.json"'s data is available as "myData").
 
+Exit Codes:
+  A summary of what went wrong is printed at the end of the build.
+  The build then exits with one of the following codes, depending on --fail-on (errors always make it fail):
+
+	0: success
+	1: errors were logged
+	2: warnings were logged
+	3: dead links were found
+	4: messages are missing from translations
+
 Build Progress:
   For integration purposes, the current build progress can be written to a file.
   The progress information is written as JSON, and has the following structure:
@@ -55,48 +95,127 @@ Build Progress:
 		output: The output file being generated.
 		language: The current language in which the page is being built.
 	}
+
+  With --progress-events, a line is appended for every event of the build instead, each with the
+  structure above, plus:
+
+	event: One of "step started", "page built", "error" or "build finished".
+	timestamp: When the event happened.
+	duration: For "page built", the time taken to build the page, in milliseconds.
+	skipped: For "page built", true when the page was up to date and was not re-built.
+	message: For "error", the error message.
+	totals: For "build finished", {built, skipped, errors, warnings, duration, exitCode}. duration is in milliseconds.
+	        It is emitted once the build is completely done, dead links checking included.
+	        While developing, it is emitted after each full build, with the exit code the build would have exited with.
+	        When the build was interrupted, interrupted is true and notBuilt is the number of pages not built.
+
+Atomic Builds:
+  Without --atomic, pages are written to <destination> as they are built, so a web server serving it
+  shows a mixture of old and new pages during the build (and nothing at all with --clean).
+  With --atomic, <destination> is only replaced once the build succeeded (see Exit Codes).
+  On Linux, <destination> is swapped with the staging directory atomically. Elsewhere, or on filesystems that
+  don't support it, <destination> is moved away and replaced by the staging directory: it does not exist in-between.
+  The staging directory starts as a copy of <destination> made of hard links, unless --clean is used.
+  To roll back to the previous build, replace <destination> with <destination>.previous.
+
+Interrupting:
+  On SIGINT or SIGTERM, pages being built are finished and no other page is started.
+  The pages that were not built are listed, and the sitemap, feeds and search indexes are left as they were.
+  The build then fails with exit code 1, as if errors were logged.
+  Interrupt a second time to exit immediately.
 `
 
 func main() {
+	os.Exit(run())
+}
+
+// run runs the command and returns the process' exit code.
+// Deferred cleanups have all run once it returns.
+func run() (exitCode int) {
 	defer func() {
 		if r := recover(); r != nil {
 			ortfomk.LogFatal("ortfo/mk crashed… Here's why: %s", r)
+			exitCode = 1
 		}
 	}()
 	usage := CLIUsage
 	args, _ := docopt.ParseDoc(usage)
+	logFormat, _ := args.String("--log-format")
+	if err := ortfomk.SetLogFormat(logFormat); err != nil {
+		ortfomk.LogError("Invalid --log-format: %s", err)
+		return 1
+	}
 	isSilent, _ := args.Bool("--silent")
+	listRoutes, _ := args.Bool("routes")
+	if listRoutes {
+		// Don't mix the spinner with the routes list
+		isSilent = true
+	}
 	clean, _ := args.Bool("--clean")
+	atomicOutput, _ := args.Bool("--atomic")
+	developing, _ := args.Bool("develop")
+	noCache, _ := args.Bool("--no-cache")
+	listStale, _ := args.Bool("--list-stale")
+	allowCollisions, _ := args.Bool("--allow-collisions")
+	failOnValue, _ := args.String("--fail-on")
+	failOn, err := ortfomk.ParseFailOn(failOnValue)
+	if err != nil {
+		ortfomk.LogError("Invalid --fail-on: %s", err)
+		return 1
+	}
+	workers, err := intOption(args, "--workers")
+	if err != nil {
+		ortfomk.LogError("Invalid --workers: %s", err)
+		return 1
+	}
+	linkCheckWorkers, err := intOption(args, "--link-check-workers")
+	if err != nil {
+		ortfomk.LogError("Invalid --link-check-workers: %s", err)
+		return 1
+	}
 	progressFilePath, _ := args.String("--write-progress")
+	progressEventsPath, _ := args.String("--progress-events")
+	showTimings, _ := args.Bool("--timings")
+	timingsFilePath, _ := args.String("--write-timings")
 	outputDirectory, _ := args.String("<destination>")
+	databaseDirectory, _ := args.String("<database>")
 	templatesDirectory, _ := args.String("<templates>")
 	templatesDirectory, _ = filepath.Abs(templatesDirectory)
 	flags := ortfomk.Flags{
-		Silent:       isSilent,
-		ProgressFile: progressFilePath,
+		Silent:          isSilent,
+		ProgressFile:    progressFilePath,
+		ProgressEvents:  progressEventsPath,
+		NoCache:         noCache,
+		ListStale:       listStale,
+		AllowCollisions: allowCollisions,
+		Workers:         workers,
 	}
 	configPath, _ := args.String("--config")
-	config, err := ortfomk.LoadConfiguration(configPath)
+	builder := ortfomk.NewBuilder(templatesDirectory, outputDirectory, ortfomk.DefaultConfiguration(), flags)
+	config, err := builder.LoadConfiguration(configPath)
 	if err != nil {
-		ortfomk.LogError("Could not load configuration: %s", err)
-		return
+		builder.LogError("Could not load configuration: %s", err)
+		return 1
 	}
+	builder.Configuration = config
 	additionalDataFiles, _ := args["--load"].([]string)
-	additionalData, err := ortfomk.LoadAdditionalData(append(additionalDataFiles, config.AdditionalData...))
+	additionalData, err := builder.LoadAdditionalData(append(additionalDataFiles, config.AdditionalData...))
 	if err != nil {
-		ortfomk.LogFatal("couldn't load data files %v: %s", additionalDataFiles, err)
-		return
+		builder.LogFatal("couldn't load data files %v: %s", additionalDataFiles, err)
+		return 1
 	}
+	builder.AdditionalData = additionalData
 
-	ortfomk.WarmUp(&ortfomk.GlobalData{
-		Flags:              flags,
-		OutputDirectory:    outputDirectory,
-		TemplatesDirectory: templatesDirectory,
-		HTTPLinks:          make(map[string][]string),
-		AdditionalData:     additionalData,
-		Configuration:      config,
-	})
-	defer ortfomk.CoolDown()
+	builder.WarmUp()
+	defer builder.CoolDown()
+	// Interrupting cancels ctx: pages being built are finished, and the build stops there.
+	// Interrupting a second time exits right away.
+	ctx, stopNotifying := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopNotifying()
+	go func() {
+		<-ctx.Done()
+		stopNotifying()
+	}()
 
 	if os.Getenv("DEBUG") == "1" {
 		cpuProfileFile, err := os.Create("ortfomk_cpu.pprof")
@@ -110,101 +229,146 @@ func main() {
 	//
 	// Preparing dist directory
 	//
-	if _, err := os.Stat(outputDirectory); err == nil && clean {
+	var staged *ortfomk.StagedOutput
+	if atomicOutput && !developing && !listRoutes {
+		staged, err = ortfomk.StageOutput(outputDirectory, clean)
+		if err != nil {
+			builder.LogError("Could not prepare the staging directory: %s", err)
+			return 1
+		}
+		// Does nothing once the staging directory replaced the output directory
+		defer func() {
+			if err := staged.Abort(); err != nil {
+				builder.LogError("Could not throw away the staging directory: %s", err)
+			}
+		}()
+		builder.OutputDirectory = staged.Staging
+	} else if _, err := os.Stat(outputDirectory); err == nil && clean {
 		os.RemoveAll(outputDirectory)
 	}
 	//
 	// Loading files
 	//
 
-	db, err := ortfomk.LoadDatabase("database")
+	db, err := builder.LoadDatabase(append([]string{databaseDirectory}, config.Database.Roots...)...)
 	if err != nil {
-		ortfomk.LogError("Could not load the database: %s", err)
+		builder.LogError("Could not load the database: %s", err)
+		return 1
+	}
+	builder.Database = db
+	if listRoutes {
+		asJSON, _ := args.Bool("--json")
+		routes, routesErr := builder.Routes(templatesDirectory)
+		var skipped *ortfomk.RoutesError
+		if routesErr != nil && !errors.As(routesErr, &skipped) {
+			builder.LogError("Could not list routes: %s", routesErr)
+			return 1
+		}
+		if err := ortfomk.WriteRoutes(os.Stdout, routes, asJSON); err != nil {
+			builder.LogError("Could not write routes: %s", err)
+			return 1
+		}
+		if skipped != nil {
+			for _, err := range skipped.Errors {
+				builder.LogError("Route not listed: %s", err)
+			}
+			return 1
+		}
 		return
 	}
-	translations, err := ortfomk.LoadTranslations()
+	translations, err := builder.LoadTranslations()
 	if err != nil {
-		ortfomk.LogError("Couldn't load the translation files: %s", err)
-		return
+		builder.LogError("Couldn't load the translation files: %s", err)
+		return 1
+	}
+	builder.Translations = translations
+	manifest, err := ortfomk.LoadBuildManifest(builder.BuildManifestPath(builder.OutputDirectory))
+	if err != nil {
+		builder.LogWarning("Couldn't load the previous build's manifest, re-building every page: %s", err)
 	}
-	ortfomk.SetTranslationsOnGlobalData(translations)
-	ortfomk.SetDatabaseOnGlobalData(db)
-	ortfomk.ComputeTotalToBuildCount()
+	builder.Manifest = manifest
+	if staged != nil {
+		// The manifest of a staged build is only right once the staging directory replaced the output directory
+		if err := staged.RestoreOnAbort(builder.BuildManifestPath(builder.OutputDirectory)); err != nil {
+			builder.LogError("Could not save the previous build's manifest: %s", err)
+			return 1
+		}
+	}
+	builder.ComputeTotalToBuildCount()
 	var httpLinks map[string][]string
 	//
 	// Watch mode
 	//
-	if val, _ := args.Bool("develop"); val {
+	if developing {
 		os.Setenv("ENV", "dev")
 
-		go ortfomk.StartDevServer("localhost:8899", "en")
+		go builder.StartDevServer("localhost:8899", config.SourceLanguage)
 
-		_, httpLinks, err = ortfomk.BuildAll(templatesDirectory, 0)
+		_, httpLinks, err = builder.BuildAll(ctx, templatesDirectory, 0)
 		if err != nil {
-			ortfomk.LogError("During initial build: %s", err)
+			builder.LogError("During initial build: %s", err)
 		}
+		builder.BuildFinished(builder.CurrentBuildReport().ExitCode(failOn))
 
-		ortfomk.StartWatcher(db)
+		builder.StartWatcher(ctx, db, failOn)
 	} else {
-		_, httpLinks, err = ortfomk.BuildAll(templatesDirectory, 0)
+		_, httpLinks, err = builder.BuildAll(ctx, templatesDirectory, 0)
+		buildFailed := err != nil
 
-		if err != nil {
-			ortfomk.LogError("While building: %s", err)
+		var interruption *ortfomk.BuildInterruptedError
+		if errors.As(err, &interruption) {
+			// Logged as an error, so that interrupted builds fail whatever --fail-on is
+			builder.LogError("Build interrupted: %d pages were built, these %d pages were not:", len(interruption.Built), len(interruption.NotBuilt))
+			for _, outPath := range interruption.NotBuilt {
+				builder.LogInfo("- %s", outPath)
+			}
+		} else if err != nil {
+			builder.LogError("While building: %s", err)
 		}
 
-		for _, lang := range []string{"fr", "en"} {
+		for _, lang := range config.Languages {
 			// Save the updated .po file
 			translations[lang].SavePO()
+			if interruption != nil {
+				// Messages of the pages that were not built would be listed as unused
+				continue
+			}
 			// Save list of unused messages
 			err = translations[lang].WriteUnusedMessages()
 			if err != nil {
-				ortfomk.LogError("While writing unused message file: %s", err)
+				builder.LogError("While writing unused message file: %s", err)
 			}
 		}
 
 		// Check for dead links
-		if os.Getenv("DEADLINKS_CHECK") != "0" {
-			ortfomk.Status(ortfomk.StepDeadLinks, ortfomk.ProgressDetails{})
-			noneDead := true
-			channel := make(chan string)
-			var wg sync.WaitGroup
-			workersCount := len(httpLinks)
-			wg.Add(workersCount)
-
-			for i := 0; i < workersCount; i++ {
-				go func(c chan string) {
-					for {
-						link, more := <-c
-						if !more {
-							wg.Done()
-							return
-						}
-
-						dead, err := ortfomk.IsLinkDead(link)
-						if err != nil {
-							ortfomk.LogError("could not check for dead link %q: %s", link, err)
-						}
-						if dead {
-							noneDead = false
-							ortfomk.LogInfo("- %s (from %s)", link, strings.Join(httpLinks[link], ", "))
-						}
-					}
-				}(channel)
+		if os.Getenv("DEADLINKS_CHECK") != "0" && ctx.Err() == nil {
+			deadlinks, err := builder.CheckDeadLinks(ctx, httpLinks, linkCheckWorkers)
+			if err != nil {
+				builder.LogWarning("Dead links check interrupted, not every link was checked.")
+			} else if len(deadlinks) == 0 {
+				builder.LogInfo("No dead links found.")
+			} else {
+				builder.LogInfo("are dead links.")
 			}
+		}
 
-			for link, _ := range httpLinks {
-				channel <- link
-			}
+		if showTimings || timingsFilePath != "" {
+			writeTimings(builder, showTimings, timingsFilePath)
+		}
 
-			close(channel)
-			wg.Wait()
+		report := builder.CurrentBuildReport()
+		builder.LogInfo("Build finished: %s", report.Summary())
+		exitCode = report.ExitCode(failOn)
 
-			if noneDead {
-				ortfomk.LogInfo("No dead links found.")
-			} else {
-				ortfomk.LogInfo("are dead links.")
+		if staged != nil {
+			if buildFailed || exitCode != 0 {
+				builder.LogWarning("Not replacing %s, since the build did not succeed.", outputDirectory)
+			} else if err := staged.Commit(); err != nil {
+				builder.LogError("Could not commit the staged build: %s", err)
+				exitCode = 1
 			}
 		}
+		builder.BuildFinished(exitCode)
 
 	}
 
@@ -216,17 +380,54 @@ func main() {
 		defer heapProfileFile.Close()
 		runtime.GC()
 		if err := pprof.WriteHeapProfile(heapProfileFile); err != nil {
-			ortfomk.LogFatal("couldn't write heap profile: %s", err)
+			builder.LogFatal("couldn't write heap profile: %s", err)
 		}
 	}
+	return
 }
 
-func init() {
-	c := make(chan os.Signal)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-c
-		ortfomk.CoolDown()
-		os.Exit(1)
-	}()
+// intOption returns the value of the given option, which must be a positive integer, or 0 if the option is not set.
+func intOption(args docopt.Opts, name string) (int, error) {
+	value, set := args[name].(string)
+	if !set {
+		return 0, nil
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if count <= 0 {
+		return 0, fmt.Errorf("%d is not a positive number", count)
+	}
+	return count, nil
+}
+
+// slowestTimingsShown is how many of the slowest templates and pages --timings shows.
+const slowestTimingsShown = 10
+
+// writeTimings prints the timing report of the builder's last build and/or writes it to timingsFilePath.
+func writeTimings(builder *ortfomk.Builder, show bool, timingsFilePath string) {
+	report := builder.CurrentTimingReport()
+	if show {
+		if err := report.WriteText(os.Stdout, slowestTimingsShown); err != nil {
+			builder.LogError("Could not print timings: %s", err)
+		}
+	}
+	if timingsFilePath == "" {
+		return
+	}
+	file, err := os.Create(timingsFilePath)
+	if err != nil {
+		builder.LogError("Could not write timings: %s", err)
+		return
+	}
+	defer file.Close()
+	if strings.HasSuffix(timingsFilePath, ".csv") {
+		err = report.WriteCSV(file)
+	} else {
+		err = report.WriteJSON(file)
+	}
+	if err != nil {
+		builder.LogError("Could not write timings to %s: %s", timingsFilePath, err)
+	}
 }
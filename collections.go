@@ -24,6 +24,9 @@ type Collection struct {
 	Includes    AntonmedvExpression
 	Aliases     []string
 	Works       []Work
+	// Source language of the site the collection was loaded for (see LoadCollections).
+	// Empty for collections not loaded by a Builder, which then don't fall back to the source language.
+	sourceLanguage string
 }
 
 type CollectionOneLang struct {
@@ -37,8 +40,8 @@ type CollectionOneLang struct {
 	Works       []WorkOneLang
 }
 
-func LoadCollections(filename string, works []Work, tags []Tag, technologies []Technology) (collections []Collection, err error) {
-	Status(StepLoadCollections, ProgressDetails{File: filename})
+func (g *Builder) LoadCollections(filename string, works []Work, tags []Tag, technologies []Technology) (collections []Collection, err error) {
+	g.Status(StepLoadCollections, ProgressDetails{File: filename})
 	collectionsMap := make(map[string]Collection)
 	raw, err := os.ReadFile(filename)
 	if err != nil {
@@ -47,6 +50,7 @@ func LoadCollections(filename string, works []Work, tags []Tag, technologies []T
 	err = yaml.Unmarshal(raw, &collectionsMap)
 	for id, collection := range collectionsMap {
 		collection.ID = id
+		collection.sourceLanguage = g.Configuration.SourceLanguage
 		descriptionsHTML := make(map[string]HTMLString)
 		for lang, description := range collection.Description {
 			descriptionsHTML[lang] = MarkdownParagraphToHTML(description)
@@ -63,7 +67,7 @@ func LoadCollections(filename string, works []Work, tags []Tag, technologies []T
 			}
 		}
 		collection.Works = worksInCollection
-		LogDebug("filled collection %s with works %v", collection.ID, collection.Works)
+		g.LogDebug("filled collection %s with works %v", collection.ID, collection.Works)
 		collections = append(collections, collection)
 	}
 	return
@@ -73,11 +77,16 @@ func (c *Collection) InLanguage(language string) CollectionOneLang {
 	var title string
 	var description HTMLString
 	var works []WorkOneLang
+	sourceLanguage := c.sourceLanguage
 	if translated, ok := c.Title[language]; ok {
 		title = translated
+	} else {
+		title = c.Title[sourceLanguage]
 	}
 	if translated, ok := c.Description[language]; ok {
 		description = translated
+	} else {
+		description = c.Description[sourceLanguage]
 	}
 	for _, w := range c.Works {
 		works = append(works, w.InLanguage(language))
@@ -167,15 +176,15 @@ func preprocessContainsPredicate(expr AntonmedvExpression, variables []string) (
 func evaluateContainsPredicate(preprocessedExpr AntonmedvExpression, context map[string]interface{}) (bool, error) {
 	var compiledExpr *exprVM.Program
 	var err error
-	if cached, ok := DynamicPathExpressionsCache[preprocessedExpr]; ok {
+	if cached, ok := DynamicPathExpressionsCache.Get(preprocessedExpr); ok {
 		compiledExpr = cached
 	} else {
 		LogDebug("compiling work collection predicate %q", preprocessedExpr)
 		compiledExpr, err = expr.Compile(preprocessedExpr)
-		DynamicPathExpressionsCache[preprocessedExpr] = compiledExpr
-	}
-	if err != nil {
-		return false, fmt.Errorf("invalid work collection predicate: %w", err)
+		if err != nil {
+			return false, fmt.Errorf("invalid work collection predicate: %w", err)
+		}
+		DynamicPathExpressionsCache.Add(preprocessedExpr, compiledExpr)
 	}
 
 	value, err := expr.Run(compiledExpr, context)
@@ -0,0 +1,92 @@
+package ortfomk
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// OutputClaim is a page that is built to some output file.
+type OutputClaim struct {
+	// Template path, relative to the templates directory
+	Template  string
+	Hydration string
+}
+
+// OutputPathCollision describes two pages being built to the same output file: the second one overwrites the first one.
+type OutputPathCollision struct {
+	OutPath string
+	First   OutputClaim
+	Second  OutputClaim
+}
+
+func (c OutputPathCollision) Error() string {
+	return fmt.Sprintf("%s is built both from %s with %s and from %s with %s", c.OutPath, c.First.Template, c.First.Hydration, c.Second.Template, c.Second.Hydration)
+}
+
+// outputClaims records which page claimed each output path during the current build.
+type outputClaims struct {
+	mu         sync.Mutex
+	byPath     map[string]outputClaim
+	collisions []OutputPathCollision
+}
+
+// outputClaim is an OutputClaim, with the ID of the object the page is about.
+type outputClaim struct {
+	OutputClaim
+	objectID string
+}
+
+// samePage returns whether both claims are of the same page, in possibly different languages.
+// Templates whose path doesn't depend on the language are built to the same output file in every language, which is not a collision.
+func (c outputClaim) samePage(other outputClaim) bool {
+	return c.Template == other.Template && c.objectID == other.objectID
+}
+
+// resetOutputClaims forgets claimed output paths and collisions, before starting a new full build.
+func (g *Builder) resetOutputClaims() {
+	g.outputClaims.mu.Lock()
+	defer g.outputClaims.mu.Unlock()
+	g.outputClaims.byPath = make(map[string]outputClaim)
+	g.outputClaims.collisions = nil
+}
+
+// ClaimOutputPath records that outPath is built from the given template and hydration.
+// If another page already claimed outPath during the current build, the collision is reported and returned.
+// Re-building the same page (e.g. when the watcher re-builds it), or building it in another language, is not a collision.
+func (g *Builder) ClaimOutputPath(outPath string, templateName string, hydration *Hydration) (collision *OutputPathCollision) {
+	claim := outputClaim{
+		OutputClaim: OutputClaim{Template: g.GetPathRelativeToSrcDir(templateName), Hydration: hydration.Name()},
+		objectID:    hydration.ObjectID(),
+	}
+	g.outputClaims.mu.Lock()
+	if g.outputClaims.byPath == nil {
+		g.outputClaims.byPath = make(map[string]outputClaim)
+	}
+	previous, claimed := g.outputClaims.byPath[outPath]
+	g.outputClaims.byPath[outPath] = claim
+	if claimed && !previous.samePage(claim) {
+		collision = &OutputPathCollision{OutPath: outPath, First: previous.OutputClaim, Second: claim.OutputClaim}
+		g.outputClaims.collisions = append(g.outputClaims.collisions, *collision)
+	}
+	g.outputClaims.mu.Unlock()
+
+	if collision != nil {
+		if g.Flags.AllowCollisions {
+			g.LogWarning("Output path collision: %s", collision)
+		} else {
+			g.LogError("Output path collision: %s", collision)
+		}
+	}
+	return
+}
+
+// OutputPathCollisions returns the collisions found since the last full build started, sorted by output path.
+func (g *Builder) OutputPathCollisions() []OutputPathCollision {
+	g.outputClaims.mu.Lock()
+	defer g.outputClaims.mu.Unlock()
+	collisions := make([]OutputPathCollision, len(g.outputClaims.collisions))
+	copy(collisions, g.outputClaims.collisions)
+	sort.SliceStable(collisions, func(i, j int) bool { return collisions[i].OutPath < collisions[j].OutPath })
+	return collisions
+}
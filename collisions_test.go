@@ -0,0 +1,49 @@
+package ortfomk
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimOutputPath(t *testing.T) {
+	defer SetGlobalData(g)
+	templates := t.TempDir()
+	SetGlobalData(&GlobalData{Configuration: DefaultConfiguration(), TemplatesDirectory: templates})
+
+	tag := &Hydration{language: "en", tag: Tag{Plural: "games"}}
+	tech := &Hydration{language: "en", tech: Technology{URLName: "games"}}
+	outPath := filepath.Join("dist", "en", "games.html")
+
+	assert.Nil(t, ClaimOutputPath(outPath, filepath.Join(templates, ":tag.pug"), tag))
+	assert.Nil(t, ClaimOutputPath(outPath, filepath.Join(templates, ":tag.pug"), tag), "re-building the same page is not a collision")
+	collision := ClaimOutputPath(outPath, filepath.Join(templates, ":technology.pug"), tech)
+	assert.Equal(t, &OutputPathCollision{
+		OutPath: outPath,
+		First:   OutputClaim{Template: ":tag.pug", Hydration: "games@en"},
+		Second:  OutputClaim{Template: ":technology.pug", Hydration: "games@en"},
+	}, collision)
+	assert.Equal(t, "dist/en/games.html is built both from :tag.pug with games@en and from :technology.pug with games@en", collision.Error())
+	assert.Len(t, OutputPathCollisions(), 1)
+}
+
+func TestPagesBuiltInEveryLanguageDontCollide(t *testing.T) {
+	builder := newTestBuilder(t, "languages")
+	builder.Configuration.Languages = []string{"fr", "en"}
+	builder.Configuration.SourceLanguage = "en"
+	builder.Translations["fr"] = &TranslationsOneLang{language: "fr", seenMessages: mapset.NewSet()}
+
+	_, _, err := builder.BuildAll(context.Background(), builder.TemplatesDirectory, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, builder.OutputPathCollisions())
+
+	routes, err := builder.Routes(builder.TemplatesDirectory)
+	assert.NoError(t, err)
+	assert.Len(t, routes, 2)
+	for _, route := range routes {
+		assert.False(t, route.Collision)
+	}
+}
@@ -14,6 +14,26 @@ type OutputTemplates struct {
 	Rest       string
 }
 
+// DatabaseConfiguration configures where the database's files are found.
+type DatabaseConfiguration struct {
+	// Additional database directories, merged with the one given on the command line.
+	Roots []string `yaml:"roots"`
+	// Override the standard filenames, relative to each root.
+	DatabaseFiles `yaml:",inline"`
+}
+
+// FeedsConfiguration configures the Atom and JSON feeds of works.
+type FeedsConfiguration struct {
+	// Title of the feeds, per language
+	Title map[string]string `yaml:"title"`
+	// Author of the works, named in the feeds
+	Author string `yaml:"author"`
+	// IDs of collections that get their own feed
+	Collections []string `yaml:"collections"`
+	// Names of tags that get their own feed
+	Tags []string `yaml:"tags"`
+}
+
 type Configuration struct {
 	Development struct {
 		OutputTo OutputTemplates `yaml:"output to"`
@@ -22,14 +42,26 @@ type Configuration struct {
 		UploadTo    OutputTemplates `yaml:"upload to"`
 		AvailableAt OutputTemplates `yaml:"available at"`
 	}
-	AdditionalData []string `yaml:"additional data"`
+	AdditionalData []string              `yaml:"additional data"`
+	Database       DatabaseConfiguration `yaml:"database"`
+	// Languages the site is built in. Every page is built once per language.
+	Languages []string `yaml:"languages"`
+	// SourceLanguage is the language the templates are written in.
+	// Pages in this language are not translated.
+	SourceLanguage string `yaml:"source language"`
+	// PugCompiler is either "cli" (uses the pug command) or "embedded" (uses the pug compiler bundled with ortfomk).
+	// Defaults to "embedded", or to "cli" when ortfomk was built without the pug compiler.
+	PugCompiler string `yaml:"pug compiler"`
+	// CacheDirectory is where compiled templates are cached between builds.
+	CacheDirectory string             `yaml:"cache directory"`
+	Feeds          FeedsConfiguration `yaml:"feeds"`
 }
 
-func LoadConfiguration(path string) (Configuration, error) {
+func (g *Builder) LoadConfiguration(path string) (Configuration, error) {
 	if path == "" {
 		path = "ortfomk.yaml"
 		if _, err := os.Stat(path); os.IsNotExist(err) {
-			LogWarning("No ortfomk.yaml found, using default configuration. A ortfomk.yaml file will be generated.")
+			g.LogWarning("No ortfomk.yaml found, using default configuration. A ortfomk.yaml file will be generated.")
 			defaultConfig, err := yaml.Marshal(DefaultConfiguration())
 			if err != nil {
 				panic(err)
@@ -50,7 +82,26 @@ func LoadConfiguration(path string) (Configuration, error) {
 		return Configuration{}, fmt.Errorf("while parsing configuration file: %w", err)
 	}
 
-	LogDebug("Loaded configuration: %#v", config)
+	if len(config.Languages) == 0 {
+		config.Languages = DefaultConfiguration().Languages
+	}
+	if config.SourceLanguage == "" {
+		config.SourceLanguage = DefaultConfiguration().SourceLanguage
+	}
+	if config.CacheDirectory == "" {
+		config.CacheDirectory = DefaultConfiguration().CacheDirectory
+	}
+	if config.PugCompiler == "" {
+		config.PugCompiler = DefaultConfiguration().PugCompiler
+	}
+	if !contains([]string{PugCompilerCLI, PugCompilerEmbedded}, config.PugCompiler) {
+		return Configuration{}, fmt.Errorf("unknown pug compiler %q, use %q or %q", config.PugCompiler, PugCompilerCLI, PugCompilerEmbedded)
+	}
+	if !contains(config.Languages, config.SourceLanguage) {
+		return Configuration{}, fmt.Errorf("source language %q is not one of the site's languages %v", config.SourceLanguage, config.Languages)
+	}
+
+	g.LogDebug("Loaded configuration: %#v", config)
 	return config, nil
 }
 
@@ -73,5 +124,18 @@ func DefaultConfiguration() Configuration {
 			AvailableAt: OutputTemplates{},
 		},
 		AdditionalData: []string{},
+		Database: DatabaseConfiguration{
+			Roots:         []string{},
+			DatabaseFiles: DefaultDatabaseFiles(),
+		},
+		Languages:      []string{"fr", "en"},
+		SourceLanguage: "en",
+		PugCompiler:    defaultPugCompiler(),
+		CacheDirectory: ".ortfomk-cache",
+		Feeds: FeedsConfiguration{
+			Title:       map[string]string{},
+			Collections: []string{},
+			Tags:        []string{},
+		},
 	}
 }
@@ -1,9 +1,10 @@
 package ortfomk
 
 import (
-	"errors"
+	"fmt"
 	"io/ioutil"
-	"path"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -29,6 +30,9 @@ type WorkOneLang struct {
 type Work struct {
 	db.Work
 	Metadata WorkMetadata
+	// Source language of the site the work was loaded for (see LoadWorks).
+	// Empty for works not loaded by a Builder, which then don't fall back to the source language.
+	sourceLanguage string
 }
 
 // String returns a string representation of the work.
@@ -69,8 +73,8 @@ type Database struct {
 }
 
 // LoadWorks reads the database file at filename into a []Work
-func LoadWorks(filename string) (works []Work, err error) {
-	Status(StepLoadWorks, ProgressDetails{
+func (g *Builder) LoadWorks(filename string) (works []Work, err error) {
+	g.Status(StepLoadWorks, ProgressDetails{
 		File: filename,
 	})
 	json := jsoniter.ConfigFastest
@@ -82,6 +86,7 @@ func LoadWorks(filename string) (works []Work, err error) {
 	err = json.Unmarshal(content, &works)
 	// Resolve shortcut "created" for finished + started.
 	for i, work := range works {
+		works[i].sourceLanguage = g.Configuration.SourceLanguage
 		if work.Metadata.Finished == "" && work.Metadata.Started == "" && work.Metadata.Created != "" {
 			works[i].Metadata.Finished = work.Metadata.Created
 			works[i].Metadata.Started = work.Metadata.Created
@@ -100,42 +105,239 @@ func LoadWorks(filename string) (works []Work, err error) {
 			works[i].Metadata.Thumbnails = make(map[string]map[uint16]string)
 		}
 	}
+	if err != nil {
+		return
+	}
+	// Report what can't be computed about a work now, instead of while building the pages that use it
+	for _, work := range works {
+		if err = work.check(g.Configuration.Languages); err != nil {
+			return
+		}
+	}
 	return
 }
 
-// LoadDatabase loads works, technologies and tags into a Database
-// Standard filepaths relative to databaseDir are assumed:
-// - database.json for the works
-// - tags.yaml for the tags
-// - technologies.yaml for the technologies
-func LoadDatabase(databaseDir string) (Database, error) {
-	works, err := LoadWorks(path.Join(databaseDir, "database.json"))
+// check returns an error if the work's creation date or its summary in one of the given languages can't be computed.
+func (work Work) check(languages []string) error {
+	for _, date := range []string{work.Metadata.Created, work.Metadata.Finished} {
+		if date == "" {
+			continue
+		}
+		if _, err := ParseCreationDate(date); err != nil {
+			return fmt.Errorf("while parsing creation date of %s: %w", work.ID, err)
+		}
+	}
+	for _, language := range languages {
+		if _, err := work.InLanguage(language).summary(); err != nil {
+			return fmt.Errorf("while creating the summary of %s in %s: %w", work.ID, language, err)
+		}
+	}
+	return nil
+}
+
+// DatabaseFiles holds the filenames of every part of a database, relative to the database's root directory.
+type DatabaseFiles struct {
+	Works        string `yaml:"works"`
+	Tags         string `yaml:"tags"`
+	Technologies string `yaml:"technologies"`
+	Sites        string `yaml:"sites"`
+	Collections  string `yaml:"collections"`
+}
+
+// DefaultDatabaseFiles returns the standard filenames of a database directory.
+func DefaultDatabaseFiles() DatabaseFiles {
+	return DatabaseFiles{
+		Works:        "database.json",
+		Tags:         "tags.yaml",
+		Technologies: "technologies.yaml",
+		Sites:        "sites.yaml",
+		Collections:  "collections.yaml",
+	}
+}
+
+// WithDefaults returns a copy of files where empty filenames are replaced by the standard ones.
+func (files DatabaseFiles) WithDefaults() DatabaseFiles {
+	defaults := DefaultDatabaseFiles()
+	if files.Works == "" {
+		files.Works = defaults.Works
+	}
+	if files.Tags == "" {
+		files.Tags = defaults.Tags
+	}
+	if files.Technologies == "" {
+		files.Technologies = defaults.Technologies
+	}
+	if files.Sites == "" {
+		files.Sites = defaults.Sites
+	}
+	if files.Collections == "" {
+		files.Collections = defaults.Collections
+	}
+	return files
+}
+
+// databaseSource is a database root directory along with the filenames to look for in it.
+type databaseSource struct {
+	root  string
+	files DatabaseFiles
+}
+
+// in returns the path to filename in the source's root, or "" if it does not exist.
+func (source databaseSource) in(filename string) string {
+	path := filename
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(source.root, filename)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// LoadDatabase loads works, technologies, tags, sites and collections into a Database,
+// merging the content of every given database root.
+// Each root is a directory, in which the filenames from the "database" key of the configuration are looked for
+// (see DefaultDatabaseFiles for the standard ones).
+// A root can also be the works JSON file itself, in which case its parent directory is used for the other files.
+// Files can be missing from some roots, as long as they are present in at least one of them.
+// Defining the same work, tag, technology, site or collection in two different files is an error.
+func (g *Builder) LoadDatabase(roots ...string) (Database, error) {
+	sources := make([]databaseSource, 0, len(roots))
+	for _, root := range roots {
+		source := databaseSource{root: root, files: g.Configuration.Database.DatabaseFiles.WithDefaults()}
+		if info, err := os.Stat(root); err != nil {
+			return Database{}, fmt.Errorf("while opening database %s: %w", root, err)
+		} else if !info.IsDir() {
+			source.root = filepath.Dir(root)
+			source.files.Works = filepath.Base(root)
+		}
+		sources = append(sources, source)
+	}
+
+	// Each file is loaded once, even if multiple roots resolve to it,
+	// e.g. when a filename of the configuration is an absolute path, or when a root is given twice.
+	pathsOf := func(kind string, filename func(DatabaseFiles) string) ([]string, error) {
+		paths := make([]string, 0, len(sources))
+		seen := make(map[string]bool)
+		for _, source := range sources {
+			path := source.in(filename(source.files))
+			if path == "" {
+				continue
+			}
+			absolute, err := filepath.Abs(path)
+			if err != nil {
+				absolute = filepath.Clean(path)
+			}
+			if seen[absolute] {
+				continue
+			}
+			seen[absolute] = true
+			paths = append(paths, path)
+		}
+		if len(paths) == 0 {
+			return paths, fmt.Errorf("no %s file found in database roots %s", kind, strings.Join(roots, ", "))
+		}
+		return paths, nil
+	}
+
+	var database Database
+	worksFiles, err := pathsOf("works", func(f DatabaseFiles) string { return f.Works })
 	if err != nil {
-		return Database{}, errors.New("While loading database.json: " + err.Error())
+		return Database{}, err
+	}
+	origins := make(map[string]string)
+	for _, file := range worksFiles {
+		works, err := g.LoadWorks(file)
+		if err != nil {
+			return Database{}, fmt.Errorf("while loading %s: %w", file, err)
+		}
+		database.Works, err = mergeDatabaseItems(database.Works, works, origins, file, "work", func(w Work) string { return w.ID })
+		if err != nil {
+			return Database{}, err
+		}
 	}
-	tags, err := LoadTags(path.Join(databaseDir, "tags.yaml"))
+
+	tagsFiles, err := pathsOf("tags", func(f DatabaseFiles) string { return f.Tags })
 	if err != nil {
-		return Database{}, errors.New("While loading tags.yaml: " + err.Error())
+		return Database{}, err
+	}
+	origins = make(map[string]string)
+	for _, file := range tagsFiles {
+		tags, err := g.LoadTags(file)
+		if err != nil {
+			return Database{}, fmt.Errorf("while loading %s: %w", file, err)
+		}
+		database.Tags, err = mergeDatabaseItems(database.Tags, tags, origins, file, "tag", func(t Tag) string { return t.URLName() })
+		if err != nil {
+			return Database{}, err
+		}
 	}
-	techs, err := LoadTechnologies(path.Join(databaseDir, "technologies.yaml"))
+
+	techsFiles, err := pathsOf("technologies", func(f DatabaseFiles) string { return f.Technologies })
 	if err != nil {
-		return Database{}, errors.New("While loading technologies.yaml: " + err.Error())
+		return Database{}, err
 	}
-	sites, err := LoadExternalSites(path.Join(databaseDir, "sites.yaml"))
+	origins = make(map[string]string)
+	for _, file := range techsFiles {
+		techs, err := g.LoadTechnologies(file)
+		if err != nil {
+			return Database{}, fmt.Errorf("while loading %s: %w", file, err)
+		}
+		database.Technologies, err = mergeDatabaseItems(database.Technologies, techs, origins, file, "technology", func(t Technology) string { return t.URLName })
+		if err != nil {
+			return Database{}, err
+		}
+	}
+
+	sitesFiles, err := pathsOf("sites", func(f DatabaseFiles) string { return f.Sites })
 	if err != nil {
-		return Database{}, errors.New("While loading sites.yaml: " + err.Error())
+		return Database{}, err
 	}
-	collections, err := LoadCollections(path.Join(databaseDir, "collections.yaml"), works, tags, techs)
+	origins = make(map[string]string)
+	for _, file := range sitesFiles {
+		sites, err := g.LoadExternalSites(file)
+		if err != nil {
+			return Database{}, fmt.Errorf("while loading %s: %w", file, err)
+		}
+		database.Sites, err = mergeDatabaseItems(database.Sites, sites, origins, file, "site", func(s ExternalSite) string { return s.Name })
+		if err != nil {
+			return Database{}, err
+		}
+	}
+
+	// Collections are loaded last, since deciding which works they include requires the complete list of works, tags and technologies.
+	collectionsFiles, err := pathsOf("collections", func(f DatabaseFiles) string { return f.Collections })
 	if err != nil {
-		return Database{}, errors.New("While loading collections.yaml: " + err.Error())
+		return Database{}, err
 	}
-	return Database{
-		Works:        works,
-		Tags:         tags,
-		Technologies: techs,
-		Sites:        sites,
-		Collections:  collections,
-	}, nil
+	origins = make(map[string]string)
+	for _, file := range collectionsFiles {
+		collections, err := g.LoadCollections(file, database.Works, database.Tags, database.Technologies)
+		if err != nil {
+			return Database{}, fmt.Errorf("while loading %s: %w", file, err)
+		}
+		database.Collections, err = mergeDatabaseItems(database.Collections, collections, origins, file, "collection", func(c Collection) string { return c.ID })
+		if err != nil {
+			return Database{}, err
+		}
+	}
+
+	return database, nil
+}
+
+// mergeDatabaseItems appends items loaded from source to merged.
+// origins maps identifiers of already-merged items to the file they were loaded from,
+// and is used to return an error when two files define an item with the same identifier.
+func mergeDatabaseItems[T any](merged []T, items []T, origins map[string]string, source string, kind string, identifier func(T) string) ([]T, error) {
+	for _, item := range items {
+		id := identifier(item)
+		if previousSource, defined := origins[id]; defined {
+			return merged, fmt.Errorf("%s %q is defined both in %s and in %s", kind, id, previousSource, source)
+		}
+		origins[id] = source
+		merged = append(merged, item)
+	}
+	return merged, nil
 }
 
 // Created returns the creation date of a work
@@ -151,8 +353,8 @@ func (work *WorkOneLang) Created() time.Time {
 	}
 	parsedDate, err := ParseCreationDate(creationDate)
 	if err != nil {
-		LogError("Error while parsing creation date of %v:", work.ID)
-		panic(err)
+		// Invalid dates are reported when loading the works, see LoadWorks
+		panic(fmt.Errorf("while parsing creation date of %s: %w", work.ID, err))
 	}
 	return parsedDate
 }
@@ -162,37 +364,49 @@ func (work WorkOneLang) IsWIP() bool {
 	return work.Metadata.WIP || (work.Metadata.Started != "" && (work.Metadata.Created != "" || work.Metadata.Finished != ""))
 }
 
-// InLanguage returns a Work object with data from only the selected language (or the default if not found)
+// InLanguage returns a Work object with data from only the selected language
+// (or the default if not found, or the source language if there's no default either)
 func (work Work) InLanguage(lang string) WorkOneLang {
 	var title string
 	var paragraphs []db.Paragraph
 	var media []db.Media
 	var links []db.Link
 	var footnotes db.Footnotes
+	sourceLanguage := work.sourceLanguage
 	if len(work.Title[lang]) > 0 {
 		title = work.Title[lang]
-	} else {
+	} else if len(work.Title["default"]) > 0 {
 		title = work.Title["default"]
+	} else {
+		title = work.Title[sourceLanguage]
 	}
 	if len(work.Paragraphs[lang]) > 0 {
 		paragraphs = work.Paragraphs[lang]
-	} else {
+	} else if len(work.Paragraphs["default"]) > 0 {
 		paragraphs = work.Paragraphs["default"]
+	} else {
+		paragraphs = work.Paragraphs[sourceLanguage]
 	}
 	if len(work.Media[lang]) > 0 {
 		media = work.Media[lang]
-	} else {
+	} else if len(work.Media["default"]) > 0 {
 		media = work.Media["default"]
+	} else {
+		media = work.Media[sourceLanguage]
 	}
 	if len(work.Links[lang]) > 0 {
 		links = work.Links[lang]
-	} else {
+	} else if len(work.Links["default"]) > 0 {
 		links = work.Links["default"]
+	} else {
+		links = work.Links[sourceLanguage]
 	}
 	if len(work.Footnotes[lang]) > 0 {
 		footnotes = work.Footnotes[lang]
-	} else {
+	} else if len(work.Footnotes["default"]) > 0 {
 		footnotes = work.Footnotes["default"]
+	} else {
+		footnotes = work.Footnotes[sourceLanguage]
 	}
 	return WorkOneLang{
 		ID:         work.ID,
@@ -229,7 +443,7 @@ func MarkdownParagraphToHTML(markdown string) string {
 }
 
 // PublicWorks returns Works that are not private
-func (g *GlobalData) PublicWorks() (works []Work) {
+func (g *Builder) PublicWorks() (works []Work) {
 	for _, w := range g.Works {
 		if !w.Metadata.Private {
 			works = append(works, w)
@@ -0,0 +1,68 @@
+package ortfomk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeDatabaseItems(t *testing.T) {
+	origins := make(map[string]string)
+	identity := func(s string) string { return s }
+
+	merged, err := mergeDatabaseItems([]string{}, []string{"a", "b"}, origins, "shared/tags.yaml", "tag", identity)
+	assert.NoError(t, err)
+	merged, err = mergeDatabaseItems(merged, []string{"c"}, origins, "site/tags.yaml", "tag", identity)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, merged)
+
+	_, err = mergeDatabaseItems(merged, []string{"b"}, origins, "other/tags.yaml", "tag", identity)
+	assert.EqualError(t, err, `tag "b" is defined both in shared/tags.yaml and in other/tags.yaml`)
+}
+
+func TestWorkCheck(t *testing.T) {
+	work := Work{Metadata: WorkMetadata{Created: "2021-04-??"}}
+	work.ID = "portfolio"
+	assert.NoError(t, work.check([]string{"en"}))
+
+	work.Metadata.Created = "last spring"
+	assert.ErrorContains(t, work.check([]string{"en"}), "while parsing creation date of portfolio")
+}
+
+func TestLoadDatabaseFromMultipleRoots(t *testing.T) {
+	shared, site := t.TempDir(), t.TempDir()
+	write := func(dir string, name string, content string) {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write(shared, "database.json", `[{"id": "portfolio", "metadata": {}}]`)
+	write(shared, "tags.yaml", "- singular: poster\n  plural: posters\n")
+	write(shared, "technologies.yaml", "- slug: go\n  name: Go\n")
+	write(shared, "sites.yaml", "- name: mastodon\n")
+	write(shared, "collections.yaml", "everything:\n  includes: 'true'\n")
+	write(site, "database.json", `[{"id": "ideaseed", "metadata": {}}]`)
+	write(site, "tags.yaml", "- singular: website\n  plural: websites\n")
+
+	builder := NewBuilder(t.TempDir(), t.TempDir(), DefaultConfiguration(), Flags{})
+	builder.Configuration.Languages = []string{"en"}
+	// Absolute paths resolve to the same file in every root
+	builder.Configuration.Database.DatabaseFiles.Sites = filepath.Join(shared, "sites.yaml")
+
+	db, err := builder.LoadDatabase(shared, site, shared)
+	assert.NoError(t, err)
+	ids := make([]string, 0)
+	for _, work := range db.Works {
+		ids = append(ids, work.ID)
+	}
+	assert.Equal(t, []string{"portfolio", "ideaseed"}, ids)
+	assert.Len(t, db.Tags, 2)
+	assert.Len(t, db.Technologies, 1)
+	assert.Len(t, db.Sites, 1)
+	assert.Len(t, db.Collections, 1)
+	assert.Len(t, db.Collections[0].Works, 2)
+
+	write(site, "technologies.yaml", "- slug: go\n  name: Golang\n")
+	_, err = builder.LoadDatabase(shared, site)
+	assert.ErrorContains(t, err, `technology "go" is defined both in `+filepath.Join(shared, "technologies.yaml"))
+}
@@ -1,9 +1,12 @@
 package ortfomk
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"regexp"
+	"strings"
+	"sync"
 
 	mapset "github.com/deckarep/golang-set"
 )
@@ -11,10 +14,20 @@ import (
 // IsLinkDead returns (true, nil) if the given link is dead (i.e. rotten).
 // An non-nil error is returned if an error occured while trying to make a GET request to the link (e.g. no Internet connection).
 func IsLinkDead(link string) (bool, error) {
-	resp, err := http.Get(link)
+	return isLinkDead(context.Background(), link)
+}
+
+// isLinkDead is IsLinkDead, with a request that is aborted when ctx is cancelled.
+func isLinkDead(ctx context.Context, link string) (bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return false, fmt.Errorf("while creating a request to %s: %w", link, err)
+	}
+	resp, err := http.DefaultClient.Do(request)
 	if err != nil {
 		return false, fmt.Errorf("while opening %s: %w", link, err)
 	}
+	resp.Body.Close()
 
 	LogDebug("got response %d for %s", resp.StatusCode, link)
 	return resp.StatusCode >= 400, nil
@@ -48,3 +61,51 @@ func Deadlinks(links mapset.Set) (deadlinks []string, err error) {
 	}
 	return
 }
+
+// CheckDeadLinks checks, in parallel, whether links (mapped to the pages they are found in) are dead, and records dead ones in the build report.
+// It uses the given number of goroutines (workersCount). If workersCount is 0 or less, DefaultLinkCheckWorkers is used.
+// Once ctx is cancelled, links are not checked anymore: the dead links found so far are returned, along with ctx's error.
+func (g *Builder) CheckDeadLinks(ctx context.Context, links map[string][]string, workersCount int) (deadlinks []string, err error) {
+	g.Status(StepDeadLinks, ProgressDetails{})
+	if workersCount <= 0 {
+		workersCount = DefaultLinkCheckWorkers
+	}
+	toCheck := make(chan string)
+	var deadlinksMutex sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(workersCount)
+
+	for i := 0; i < workersCount; i++ {
+		go func() {
+			defer wg.Done()
+			for link := range toCheck {
+				dead, err := isLinkDead(ctx, link)
+				if err != nil {
+					if ctx.Err() == nil {
+						g.LogError("could not check for dead link %q: %s", link, err)
+					}
+					continue
+				}
+				if dead {
+					deadlinksMutex.Lock()
+					deadlinks = append(deadlinks, link)
+					deadlinksMutex.Unlock()
+					g.RecordDeadLink(link, links[link])
+					g.LogInfo("- %s (from %s)", link, strings.Join(links[link], ", "))
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for link := range links {
+		select {
+		case toCheck <- link:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(toCheck)
+	wg.Wait()
+	return deadlinks, ctx.Err()
+}
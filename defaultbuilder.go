@@ -0,0 +1,371 @@
+package ortfomk
+
+import (
+	"context"
+	"golang.org/x/net/html"
+)
+
+// The functions of this file build with the default builder, g.
+// They predate Builder, and are kept so that existing callers keep working:
+// new code should create its own builder with NewBuilder and use its methods instead.
+
+// WarmUp sets the default builder to data, then warms it up (see Builder.WarmUp).
+func WarmUp(data *GlobalData) {
+	g = data
+	g.WarmUp()
+}
+
+// CoolDown is Builder.CoolDown, on the default builder.
+func CoolDown() {
+	g.CoolDown()
+}
+
+// LoadAdditionalData is Builder.LoadAdditionalData, on the default builder.
+func LoadAdditionalData(filesToLoad []string) (additionalData map[string]interface{}, err error) {
+	return g.LoadAdditionalData(filesToLoad)
+}
+
+// ComputeTotalToBuildCount is Builder.ComputeTotalToBuildCount, on the default builder.
+func ComputeTotalToBuildCount() {
+	g.ComputeTotalToBuildCount()
+}
+
+// ToBuildTotalCount is Builder.ToBuildTotalCount, on the default builder.
+func ToBuildTotalCount(in string) (count int) {
+	return g.ToBuildTotalCount(in)
+}
+
+// BuildAll is Builder.BuildAll, on the default builder.
+func BuildAll(ctx context.Context, in string, workersCount int) (built []string, httpLinks map[string][]string, err error) {
+	return g.BuildAll(ctx, in, workersCount)
+}
+
+// ScanAll is Builder.ScanAll, on the default builder.
+func ScanAll(in string) (toBuild []string, err error) {
+	return g.ScanAll(in)
+}
+
+// BuildTechPages is Builder.BuildTechPages, on the default builder.
+func BuildTechPages(ctx context.Context, using string) (built []string) {
+	return g.BuildTechPages(ctx, using)
+}
+
+// BuildSitePages is Builder.BuildSitePages, on the default builder.
+func BuildSitePages(ctx context.Context, using string) (built []string) {
+	return g.BuildSitePages(ctx, using)
+}
+
+// BuildTagPages is Builder.BuildTagPages, on the default builder.
+func BuildTagPages(ctx context.Context, using string) (built []string) {
+	return g.BuildTagPages(ctx, using)
+}
+
+// BuildCollectionPages is Builder.BuildCollectionPages, on the default builder.
+func BuildCollectionPages(ctx context.Context, using string) (built []string) {
+	return g.BuildCollectionPages(ctx, using)
+}
+
+// BuildWorkPages is Builder.BuildWorkPages, on the default builder.
+func BuildWorkPages(ctx context.Context, using string) (built []string) {
+	return g.BuildWorkPages(ctx, using)
+}
+
+// BuildRegularPage is Builder.BuildRegularPage, on the default builder.
+func BuildRegularPage(ctx context.Context, path string) (built []string) {
+	return g.BuildRegularPage(ctx, path)
+}
+
+// BuildPage is Builder.BuildPage, on the default builder.
+func BuildPage(ctx context.Context, javascriptRuntime *JSRuntime, pageName string, compiledTemplate []byte, hydration *Hydration) (built []string) {
+	return g.BuildPage(ctx, javascriptRuntime, pageName, compiledTemplate, hydration)
+}
+
+// LoadCollections is Builder.LoadCollections, on the default builder.
+func LoadCollections(filename string, works []Work, tags []Tag, technologies []Technology) (collections []Collection, err error) {
+	return g.LoadCollections(filename, works, tags, technologies)
+}
+
+// ClaimOutputPath is Builder.ClaimOutputPath, on the default builder.
+func ClaimOutputPath(outPath string, templateName string, hydration *Hydration) (collision *OutputPathCollision) {
+	return g.ClaimOutputPath(outPath, templateName, hydration)
+}
+
+// OutputPathCollisions is Builder.OutputPathCollisions, on the default builder.
+func OutputPathCollisions() []OutputPathCollision {
+	return g.OutputPathCollisions()
+}
+
+// LoadConfiguration is Builder.LoadConfiguration, on the default builder.
+func LoadConfiguration(path string) (Configuration, error) {
+	return g.LoadConfiguration(path)
+}
+
+// LoadWorks is Builder.LoadWorks, on the default builder.
+func LoadWorks(filename string) (works []Work, err error) {
+	return g.LoadWorks(filename)
+}
+
+// LoadDatabase is Builder.LoadDatabase, on the default builder.
+func LoadDatabase(roots ...string) (Database, error) {
+	return g.LoadDatabase(roots...)
+}
+
+// CheckDeadLinks is Builder.CheckDeadLinks, on the default builder.
+func CheckDeadLinks(ctx context.Context, links map[string][]string, workersCount int) (deadlinks []string, err error) {
+	return g.CheckDeadLinks(ctx, links, workersCount)
+}
+
+// StartDevServer is Builder.StartDevServer, on the default builder.
+func StartDevServer(host string, language string) {
+	g.StartDevServer(host, language)
+}
+
+// RecordBuildFailure is Builder.RecordBuildFailure, on the default builder.
+func RecordBuildFailure(outPath string, templateName string, hydration *Hydration, err error) {
+	g.RecordBuildFailure(outPath, templateName, hydration, err)
+}
+
+// ClearBuildFailure is Builder.ClearBuildFailure, on the default builder.
+func ClearBuildFailure(outPath string) {
+	g.ClearBuildFailure(outPath)
+}
+
+// BuildFailureOf is Builder.BuildFailureOf, on the default builder.
+func BuildFailureOf(outPath string) (BuildFailure, bool) {
+	return g.BuildFailureOf(outPath)
+}
+
+// Feeds is Builder.Feeds, on the default builder.
+func Feeds() (feeds []Feed) {
+	return g.Feeds()
+}
+
+// WriteFeeds is Builder.WriteFeeds, on the default builder.
+func WriteFeeds() (written []string, err error) {
+	return g.WriteFeeds()
+}
+
+// PrintTemplateErrorMessage is Builder.PrintTemplateErrorMessage, on the default builder.
+func PrintTemplateErrorMessage(whileDoing string, templateName string, templateContent string, err error, templateLanguage string) {
+	g.PrintTemplateErrorMessage(whileDoing, templateName, templateContent, err, templateLanguage)
+}
+
+// RunTemplate is Builder.RunTemplate, on the default builder.
+func RunTemplate(javascriptRuntime *JSRuntime, hydration *Hydration, templateName string, compiledTemplate []byte) (string, error) {
+	return g.RunTemplate(javascriptRuntime, hydration, templateName, compiledTemplate)
+}
+
+// TriggerLiveReload is Builder.TriggerLiveReload, on the default builder.
+func TriggerLiveReload(outputPaths []string) {
+	g.TriggerLiveReload(outputPaths)
+}
+
+// BuildManifestPath is Builder.BuildManifestPath, on the default builder.
+func BuildManifestPath(outputDirectory string) string {
+	return g.BuildManifestPath(outputDirectory)
+}
+
+// PageInputs is Builder.PageInputs, on the default builder.
+func PageInputs(file GeneratedJSFile) BuildManifestEntry {
+	return g.PageInputs(file)
+}
+
+// WritePDF is Builder.WritePDF, on the default builder.
+func WritePDF(html string, to string) error {
+	return g.WritePDF(html, to)
+}
+
+// OpenProgressEvents is Builder.OpenProgressEvents, on the default builder.
+func OpenProgressEvents(path string) error {
+	return g.OpenProgressEvents(path)
+}
+
+// CloseProgressEvents is Builder.CloseProgressEvents, on the default builder.
+func CloseProgressEvents() {
+	g.CloseProgressEvents()
+}
+
+// BuildFinished is Builder.BuildFinished, on the default builder.
+func BuildFinished(exitCode int) {
+	g.BuildFinished(exitCode)
+}
+
+// Status is Builder.Status, on the default builder.
+func Status(step BuildStep, details ProgressDetails) {
+	g.Status(step, details)
+}
+
+// IncrementProgress is Builder.IncrementProgress, on the default builder.
+func IncrementProgress() error {
+	return g.IncrementProgress()
+}
+
+// WriteProgressFile is Builder.WriteProgressFile, on the default builder.
+func WriteProgressFile() error {
+	return g.WriteProgressFile()
+}
+
+// SetCurrentObjectID is Builder.SetCurrentObjectID, on the default builder.
+func SetCurrentObjectID(objectID string) {
+	g.SetCurrentObjectID(objectID)
+}
+
+// RecordDeadLink is Builder.RecordDeadLink, on the default builder.
+func RecordDeadLink(link string, pages []string) {
+	g.RecordDeadLink(link, pages)
+}
+
+// CurrentBuildReport is Builder.CurrentBuildReport, on the default builder.
+func CurrentBuildReport() BuildReport {
+	return g.CurrentBuildReport()
+}
+
+// HydrationsOf is Builder.HydrationsOf, on the default builder.
+func HydrationsOf(path string) (hydrations []*Hydration, err error) {
+	return g.HydrationsOf(path)
+}
+
+// Routes is Builder.Routes, on the default builder.
+func Routes(in string) (routes []Route, err error) {
+	return g.Routes(in)
+}
+
+// SearchIndexPath is Builder.SearchIndexPath, on the default builder.
+func SearchIndexPath(language string) string {
+	return g.SearchIndexPath(language)
+}
+
+// BuildSearchIndex is Builder.BuildSearchIndex, on the default builder.
+func BuildSearchIndex(language string) SearchIndex {
+	return g.BuildSearchIndex(language)
+}
+
+// WriteSearchIndexes is Builder.WriteSearchIndexes, on the default builder.
+func WriteSearchIndexes() (written []string, err error) {
+	return g.WriteSearchIndexes()
+}
+
+// PublicURLOf is Builder.PublicURLOf, on the default builder.
+func PublicURLOf(language string, outPath string) (url string, ok bool) {
+	return g.PublicURLOf(language, outPath)
+}
+
+// WriteSitemap is Builder.WriteSitemap, on the default builder.
+func WriteSitemap() (written []string, err error) {
+	return g.WriteSitemap()
+}
+
+// RemoveStaleOutputs is Builder.RemoveStaleOutputs, on the default builder.
+func RemoveStaleOutputs(manifest *BuildManifest, dryRun bool) (stale []string) {
+	return g.RemoveStaleOutputs(manifest, dryRun)
+}
+
+// LoadTechnologies is Builder.LoadTechnologies, on the default builder.
+func LoadTechnologies(filename string) (technologies []Technology, err error) {
+	return g.LoadTechnologies(filename)
+}
+
+// LoadExternalSites is Builder.LoadExternalSites, on the default builder.
+func LoadExternalSites(filename string) (sites []ExternalSite, err error) {
+	return g.LoadExternalSites(filename)
+}
+
+// LoadTags is Builder.LoadTags, on the default builder.
+func LoadTags(filename string) (tags []Tag, err error) {
+	return g.LoadTags(filename)
+}
+
+// GenerateJSFile is Builder.GenerateJSFile, on the default builder.
+func GenerateJSFile(hydration *Hydration, templateName string, compiledPugTemplate string) (GeneratedJSFile, error) {
+	return g.GenerateJSFile(hydration, templateName, compiledPugTemplate)
+}
+
+// CompileTemplate is Builder.CompileTemplate, on the default builder.
+func CompileTemplate(templateName string, templateContent []byte) ([]byte, error) {
+	return g.CompileTemplate(templateName, templateContent)
+}
+
+// TemplateCacheKey is Builder.TemplateCacheKey, on the default builder.
+func TemplateCacheKey(templateName string, templateContent []byte) (string, error) {
+	return g.TemplateCacheKey(templateName, templateContent)
+}
+
+// TransitiveDependencies is Builder.TransitiveDependencies, on the default builder.
+func TransitiveDependencies(templateName string, templateContent []byte) ([]string, error) {
+	return g.TransitiveDependencies(templateName, templateContent)
+}
+
+// CurrentTimingReport is Builder.CurrentTimingReport, on the default builder.
+func CurrentTimingReport() TimingReport {
+	return g.CurrentTimingReport()
+}
+
+// Translate is Builder.Translate, on the default builder.
+func Translate(language string, root *html.Node) string {
+	return g.Translate(language, root)
+}
+
+// LoadTranslations is Builder.LoadTranslations, on the default builder.
+func LoadTranslations() (Translations, error) {
+	return g.LoadTranslations()
+}
+
+// CreateSpinner is Builder.CreateSpinner, on the default builder.
+func CreateSpinner() Spinner {
+	return g.CreateSpinner()
+}
+
+// UpdateSpinner is Builder.UpdateSpinner, on the default builder.
+func UpdateSpinner() {
+	g.UpdateSpinner()
+}
+
+// LogError is Builder.LogError, on the default builder.
+func LogError(message string, fmtArgs ...interface{}) {
+	g.LogError(message, fmtArgs...)
+}
+
+// LogFatal is Builder.LogFatal, on the default builder.
+func LogFatal(message string, fmtArgs ...interface{}) {
+	g.LogFatal(message, fmtArgs...)
+}
+
+// LogInfo is Builder.LogInfo, on the default builder.
+func LogInfo(message string, fmtArgs ...interface{}) {
+	g.LogInfo(message, fmtArgs...)
+}
+
+// LogDebug is Builder.LogDebug, on the default builder.
+func LogDebug(message string, fmtArgs ...interface{}) {
+	g.LogDebug(message, fmtArgs...)
+}
+
+// LogWarning is Builder.LogWarning, on the default builder.
+func LogWarning(message string, fmtArgs ...interface{}) {
+	g.LogWarning(message, fmtArgs...)
+}
+
+// StartWatcher is Builder.StartWatcher, on the default builder.
+func StartWatcher(ctx context.Context, db Database, failOn []string) {
+	g.StartWatcher(ctx, db, failOn)
+}
+
+// UpdateExtendsStatement is Builder.UpdateExtendsStatement, on the default builder.
+func UpdateExtendsStatement(in string, from string, to string) {
+	g.UpdateExtendsStatement(in, from, to)
+}
+
+// GetPathRelativeToSrcDir is Builder.GetPathRelativeToSrcDir, on the default builder.
+func GetPathRelativeToSrcDir(absPath string) string {
+	return g.GetPathRelativeToSrcDir(absPath)
+}
+
+// DependentsOf is Builder.DependentsOf, on the default builder.
+func DependentsOf(searchIn string, pageFilepath string, maxDepth uint) (dependents []string) {
+	return g.DependentsOf(searchIn, pageFilepath, maxDepth)
+}
+
+// GetDistFilepath is Builder.GetDistFilepath with this hydration, on the default builder.
+func (h *Hydration) GetDistFilepath(srcFilepath string) (string, error) {
+	return g.GetDistFilepath(h, srcFilepath)
+}
@@ -1,21 +1,31 @@
 package ortfomk
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pkg/browser"
 )
 
 type devserver struct {
 	language string
+	// Builder whose output directory is served
+	builder *Builder
 }
 
 func (s devserver) Open(name string) (http.File, error) {
-	LogDebug("handling %s", name)
+	g := s.builder
+	g.LogDebug("handling %s", name)
+	if page, failed := s.failurePage(name); failed {
+		return page, nil
+	}
 	// What path to choose ? Is the requested file translated, media or rest?
 	// Test them one by one, moving to the next one if not found.
 
@@ -28,38 +38,108 @@ func (s devserver) Open(name string) (http.File, error) {
 		),
 		name,
 	))
-	LogDebug("testing(translated) %q", filename)
+	g.LogDebug("testing(translated) %q", filename)
 	if err != nil {
 		return nil, fmt.Errorf("while testing for a translated page: %w", err)
 	}
 
 	if filename != "" {
-		return os.Open(filename)
+		return s.open(filename)
 	} else {
-		LogDebug("%s |-> %q not found for translated", name, filename)
+		g.LogDebug("%s |-> %q not found for translated", name, filename)
 	}
 
 	filename, err = existsOptionalHTMLExtension(filepath.Join(g.OutputDirectory, g.Configuration.Development.OutputTo.Media, name))
-	LogDebug("testing(media) %q", filename)
+	g.LogDebug("testing(media) %q", filename)
 	if err != nil {
 		return nil, fmt.Errorf("while testing for a media page: %w", err)
 	}
 
 	if filename != "" {
-		return os.Open(filename)
+		return s.open(filename)
 	} else {
-		LogDebug("%s |-> %q not found for media", name, filename)
+		g.LogDebug("%s |-> %q not found for media", name, filename)
 	}
 
 	filename, err = existsOptionalHTMLExtension(filepath.Join(g.OutputDirectory, g.Configuration.Development.OutputTo.Rest, name))
-	LogDebug("testing(rest) %q", filename)
+	g.LogDebug("testing(rest) %q", filename)
 	if err != nil {
 		return nil, fmt.Errorf("while testing for a rest page: %w", err)
 	}
 
-	return os.Open(filename)
+	return s.open(filename)
+}
+
+// failurePage returns an error page if the last build of the requested page failed.
+func (s devserver) failurePage(name string) (http.File, bool) {
+	g := s.builder
+	for _, directory := range []string{
+		strings.ReplaceAll(g.Configuration.Development.OutputTo.Translated, "<language>", s.language),
+		g.Configuration.Development.OutputTo.Rest,
+	} {
+		candidate := filepath.Join(g.OutputDirectory, directory, name)
+		for _, outPath := range []string{candidate, candidate + ".html", filepath.Join(candidate, "index.html")} {
+			failure, failed := g.BuildFailureOf(outPath)
+			if !failed {
+				continue
+			}
+			page, err := failure.HTML()
+			if err != nil {
+				g.LogError("while rendering error page for %s: %s", outPath, err)
+				return nil, false
+			}
+			return newInMemoryFile(filepath.Base(outPath), injectLiveReloadScript(page), failure.At), true
+		}
+	}
+	return nil, false
+}
+
+// open opens the given file, injecting the live reload script into HTML pages.
+func (s devserver) open(filename string) (http.File, error) {
+	if !strings.HasSuffix(filename, ".html") {
+		return os.Open(filename)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return newInMemoryFile(info.Name(), injectLiveReloadScript(content), info.ModTime()), nil
+}
+
+// inMemoryFile is an http.File whose content is held in memory instead of being read from the disk.
+type inMemoryFile struct {
+	*bytes.Reader
+	info inMemoryFileInfo
+}
+
+func newInMemoryFile(name string, content []byte, modTime time.Time) inMemoryFile {
+	return inMemoryFile{
+		Reader: bytes.NewReader(content),
+		info:   inMemoryFileInfo{name: name, size: int64(len(content)), modTime: modTime},
+	}
+}
+
+func (f inMemoryFile) Close() error                       { return nil }
+func (f inMemoryFile) Stat() (fs.FileInfo, error)         { return f.info, nil }
+func (f inMemoryFile) Readdir(int) ([]fs.FileInfo, error) { return nil, errors.New("not a directory") }
+
+type inMemoryFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
 }
 
+func (i inMemoryFileInfo) Name() string       { return i.name }
+func (i inMemoryFileInfo) Size() int64        { return i.size }
+func (i inMemoryFileInfo) Mode() fs.FileMode  { return 0o444 }
+func (i inMemoryFileInfo) ModTime() time.Time { return i.modTime }
+func (i inMemoryFileInfo) IsDir() bool        { return false }
+func (i inMemoryFileInfo) Sys() interface{}   { return nil }
+
 // returns path if exists and "" if not.
 func existsOptionalHTMLExtension(filename string) (string, error) {
 	_, err := os.Stat(filename)
@@ -76,11 +156,17 @@ func existsOptionalHTMLExtension(filename string) (string, error) {
 	return "", err
 }
 
-func StartDevServer(host string, language string) {
-	LogInfo("Starting development server on http://%s", host)
+func (g *Builder) StartDevServer(host string, language string) {
+	g.LogInfo("Starting development server on http://%s", host)
 	browser.OpenURL("http://" + host)
-	err := http.ListenAndServe(host, http.FileServer(devserver{language: language}))
+	g.reloader.mu.Lock()
+	g.reloader.language = language
+	g.reloader.mu.Unlock()
+	mux := http.NewServeMux()
+	mux.Handle(LiveReloadEndpoint, &g.reloader)
+	mux.Handle("/", http.FileServer(devserver{language: language, builder: g}))
+	err := http.ListenAndServe(host, mux)
 	if err != nil {
-		LogError("while starting development server: %s", err)
+		g.LogError("while starting development server: %s", err)
 	}
 }
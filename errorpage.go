@@ -0,0 +1,127 @@
+package ortfomk
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// BuildFailure describes why an output file could not be built.
+// In development mode, the development server shows it in place of the stale output file.
+type BuildFailure struct {
+	// Template is the name of the template, as returned by NameOfTemplate
+	Template string
+	// Hydration is the name of the hydration the template was executed with
+	Hydration string
+	// Location is where the error occured in the pug template, if known
+	Location    string
+	Message     string
+	CodeSnippet string
+	StackTrace  string
+	At          time.Time
+}
+
+// buildFailures records the pages that failed to build, by output path.
+type buildFailures struct {
+	mu     sync.Mutex
+	byPath map[string]BuildFailure
+}
+
+// RecordBuildFailure remembers that building outPath failed with err, until ClearBuildFailure is called for that path.
+// Failures are only recorded in development mode.
+func (g *Builder) RecordBuildFailure(outPath string, templateName string, hydration *Hydration, err error) {
+	if os.Getenv("ENV") != "dev" {
+		return
+	}
+	failure := BuildFailure{
+		Template:  NameOfTemplate(templateName, *hydration),
+		Hydration: hydration.Name(),
+		Message:   err.Error(),
+		At:        time.Now(),
+	}
+	var templateErr *TemplateError
+	if errors.As(err, &templateErr) {
+		failure.Message = templateErr.JSError.Message
+		failure.CodeSnippet = templateErr.CodeSnippet
+		failure.StackTrace = templateErr.StackTrace
+		if templateErr.PugFile != "" {
+			failure.Location = templateErr.PugLocation()
+			failure.CodeSnippet = templateErr.PugSnippet
+		}
+	}
+	g.buildFailures.mu.Lock()
+	if g.buildFailures.byPath == nil {
+		g.buildFailures.byPath = make(map[string]BuildFailure)
+	}
+	g.buildFailures.byPath[filepath.Clean(outPath)] = failure
+	g.buildFailures.mu.Unlock()
+	g.TriggerLiveReload([]string{outPath})
+}
+
+// ClearBuildFailure forgets about a previous failure to build outPath.
+func (g *Builder) ClearBuildFailure(outPath string) {
+	g.buildFailures.mu.Lock()
+	defer g.buildFailures.mu.Unlock()
+	delete(g.buildFailures.byPath, filepath.Clean(outPath))
+}
+
+// BuildFailureOf returns the failure recorded for outPath, if any.
+func (g *Builder) BuildFailureOf(outPath string) (BuildFailure, bool) {
+	g.buildFailures.mu.Lock()
+	defer g.buildFailures.mu.Unlock()
+	failure, failed := g.buildFailures.byPath[filepath.Clean(outPath)]
+	return failure, failed
+}
+
+var errorPageTemplate = template.Must(template.New("error page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Build failed: {{ .Template }}</title>
+	<style>
+		body { margin: 0; padding: 2em; background: #1e1e1e; color: #eee; font-family: sans-serif; }
+		h1 { color: #ff6b6b; font-size: 1.5em; }
+		dt { font-weight: bold; margin-top: 1em; }
+		pre { background: #111; padding: 1em; overflow-x: auto; border-left: 3px solid #ff6b6b; }
+		code { font-family: monospace; }
+	</style>
+</head>
+<body>
+	<h1>Couldn't build this page</h1>
+	<dl>
+		<dt>Template</dt>
+		<dd><code>{{ .Template }}</code></dd>
+		{{ if .Hydration }}
+		<dt>Hydration</dt>
+		<dd><code>{{ .Hydration }}</code></dd>
+		{{ end }}
+		{{ if .Location }}
+		<dt>Location</dt>
+		<dd><code>{{ .Location }}</code></dd>
+		{{ end }}
+		<dt>Error</dt>
+		<dd><pre><code>{{ .Message }}</code></pre></dd>
+		{{ if .CodeSnippet }}
+		<dt>Code</dt>
+		<dd><pre><code>{{ .CodeSnippet }}</code></pre></dd>
+		{{ end }}
+		{{ if .StackTrace }}
+		<dt>Stack trace</dt>
+		<dd><pre><code>{{ .StackTrace }}</code></pre></dd>
+		{{ end }}
+	</dl>
+	<p><small>Failed at {{ .At.Format "15:04:05" }}. This page reloads once the build succeeds.</small></p>
+</body>
+</html>
+`))
+
+// HTML renders the failure as an error page.
+func (f BuildFailure) HTML() ([]byte, error) {
+	var page bytes.Buffer
+	err := errorPageTemplate.Execute(&page, f)
+	return page.Bytes(), err
+}
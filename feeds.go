@@ -0,0 +1,322 @@
+package ortfomk
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Feed is a list of works, written as both an Atom feed and a JSON Feed.
+type Feed struct {
+	Language string
+	Title    string
+	// Path of the Atom feed. The JSON Feed is written next to it, with a .json extension.
+	OutPath string
+	Works   []WorkOneLang
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Language string      `xml:"xml:lang,attr,omitempty"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Updated  string      `xml:"updated"`
+	Author   *atomAuthor `xml:"author,omitempty"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Updated   string     `xml:"updated"`
+	Published string     `xml:"published"`
+	Summary   string     `xml:"summary,omitempty"`
+	Links     []atomLink `xml:"link"`
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	Language    string         `json:"language,omitempty"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Authors     []jsonAuthor   `json:"authors,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url,omitempty"`
+	Title         string               `json:"title"`
+	Summary       string               `json:"summary,omitempty"`
+	Image         string               `json:"image,omitempty"`
+	DatePublished string               `json:"date_published"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+}
+
+type jsonFeedAttachment struct {
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+}
+
+// Feeds returns the feeds of works to write: one per language,
+// plus one per language for each collection and tag listed in the configuration.
+// Works are sorted from newest to oldest. Works without any date are left out.
+func (g *Builder) Feeds() (feeds []Feed) {
+	config := g.Configuration.Feeds
+	for _, language := range g.Configuration.Languages {
+		directory := filepath.Join(g.OutputDirectory, strings.ReplaceAll(g.Configuration.Development.OutputTo.Translated, "<language>", language))
+		title := config.Title[language]
+		if title == "" {
+			title = config.Title[g.Configuration.SourceLanguage]
+		}
+
+		feeds = append(feeds, Feed{
+			Language: language,
+			Title:    title,
+			OutPath:  filepath.Join(directory, "feed.atom"),
+			Works:    datedWorks(language, g.PublicWorks()),
+		})
+
+		for _, id := range config.Collections {
+			collection, found := g.findCollection(id)
+			if !found {
+				g.LogWarning("Not writing a feed for collection %q: no such collection", id)
+				continue
+			}
+			works := make([]Work, 0)
+			for _, work := range collection.Works {
+				if !work.Metadata.Private {
+					works = append(works, work)
+				}
+			}
+			feeds = append(feeds, Feed{
+				Language: language,
+				Title:    strings.TrimSpace(title + " — " + collection.InLanguage(language).Title),
+				OutPath:  filepath.Join(directory, "feeds", "collections", collection.ID+".atom"),
+				Works:    datedWorks(language, works),
+			})
+		}
+
+		for _, name := range config.Tags {
+			tag, found := g.findTag(name)
+			if !found {
+				g.LogWarning("Not writing a feed for tag %q: no such tag", name)
+				continue
+			}
+			works := make([]Work, 0)
+			for _, work := range g.PublicWorks() {
+				for _, workTag := range work.Metadata.Tags {
+					if tag.ReferredToBy(workTag) {
+						works = append(works, work)
+						break
+					}
+				}
+			}
+			feeds = append(feeds, Feed{
+				Language: language,
+				Title:    strings.TrimSpace(title + " — " + tag.Plural),
+				OutPath:  filepath.Join(directory, "feeds", "tags", tag.URLName()+".atom"),
+				Works:    datedWorks(language, works),
+			})
+		}
+	}
+	return
+}
+
+func (g *Builder) findCollection(id string) (Collection, bool) {
+	for _, collection := range g.Collections {
+		if collection.ID == id || StringsLooselyMatch(id, collection.Aliases...) {
+			return collection, true
+		}
+	}
+	return Collection{}, false
+}
+
+func (g *Builder) findTag(name string) (Tag, bool) {
+	for _, tag := range g.Tags {
+		if tag.ReferredToBy(name) {
+			return tag, true
+		}
+	}
+	return Tag{}, false
+}
+
+// datedWorks returns the given works in language, newest first, leaving out works without a date.
+func datedWorks(language string, works []Work) []WorkOneLang {
+	dated := make([]WorkOneLang, 0, len(works))
+	for _, work := range GetOneLang(language, works...) {
+		if work.Created().Year() != 9999 {
+			dated = append(dated, work)
+		}
+	}
+	sort.SliceStable(dated, func(i, j int) bool {
+		return dated[i].Created().After(dated[j].Created())
+	})
+	return dated
+}
+
+// WriteFeeds writes every feed as Atom and JSON Feed files, and returns the paths of the files written.
+// Feeds need absolute URLs, so nothing is written if the configuration does not say where the site is available at.
+func (g *Builder) WriteFeeds() (written []string, err error) {
+	if g.Configuration.Production.AvailableAt.Translated == "" && g.Configuration.Production.AvailableAt.Rest == "" {
+		g.LogDebug("not writing feeds: the configuration does not say where the site is available at")
+		return
+	}
+	for _, feed := range g.Feeds() {
+		if err := writeXML(feed.OutPath, g.atomDocument(feed)); err != nil {
+			return written, fmt.Errorf("while writing feed %s: %w", feed.OutPath, err)
+		}
+		jsonPath := strings.TrimSuffix(feed.OutPath, ".atom") + ".json"
+		content, err := json.MarshalIndent(g.jsonFeedDocument(feed), "", "  ")
+		if err != nil {
+			return written, fmt.Errorf("while encoding feed %s: %w", jsonPath, err)
+		}
+		if err := writeFileAtomically(jsonPath, content, 0o644); err != nil {
+			return written, fmt.Errorf("while writing feed %s: %w", jsonPath, err)
+		}
+		written = append(written, feed.OutPath, jsonPath)
+	}
+	return
+}
+
+// atomDocument returns the feed as an Atom document.
+func (g *Builder) atomDocument(f Feed) atomFeed {
+	selfURL, _ := g.PublicURLOf(f.Language, f.OutPath)
+	feed := atomFeed{
+		Language: f.Language,
+		ID:       selfURL,
+		Title:    f.Title,
+		Updated:  f.updated().Format(time.RFC3339),
+		Links:    []atomLink{{Rel: "self", Href: selfURL, Type: "application/atom+xml"}},
+		Entries:  make([]atomEntry, 0, len(f.Works)),
+	}
+	if homePage := g.feedHomePageURL(f); homePage != "" {
+		feed.Links = append(feed.Links, atomLink{Rel: "alternate", Href: homePage, Type: "text/html"})
+	}
+	if g.Configuration.Feeds.Author != "" {
+		feed.Author = &atomAuthor{Name: g.Configuration.Feeds.Author}
+	}
+	for _, work := range f.Works {
+		url := g.sitemap.urlOf(work.ID, f.Language)
+		entry := atomEntry{
+			ID:        workEntryID(work, url),
+			Title:     work.Title,
+			Updated:   work.Created().Format(time.RFC3339),
+			Published: work.Created().Format(time.RFC3339),
+			Summary:   work.Summary(),
+			Links:     make([]atomLink, 0),
+		}
+		if url != "" {
+			entry.Links = append(entry.Links, atomLink{Rel: "alternate", Href: url, Type: "text/html"})
+		}
+		if thumbnail := g.thumbnailURL(work); thumbnail != "" {
+			entry.Links = append(entry.Links, atomLink{Rel: "enclosure", Href: thumbnail, Type: mimeTypeOf(thumbnail)})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+// jsonFeedDocument returns the feed as a JSON Feed (version 1.1) document.
+func (g *Builder) jsonFeedDocument(f Feed) jsonFeed {
+	feedURL, _ := g.PublicURLOf(f.Language, strings.TrimSuffix(f.OutPath, ".atom")+".json")
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		Language:    f.Language,
+		HomePageURL: g.feedHomePageURL(f),
+		FeedURL:     feedURL,
+		Items:       make([]jsonFeedItem, 0, len(f.Works)),
+	}
+	if g.Configuration.Feeds.Author != "" {
+		feed.Authors = []jsonAuthor{{Name: g.Configuration.Feeds.Author}}
+	}
+	for _, work := range f.Works {
+		url := g.sitemap.urlOf(work.ID, f.Language)
+		item := jsonFeedItem{
+			ID:            workEntryID(work, url),
+			URL:           url,
+			Title:         work.Title,
+			Summary:       work.Summary(),
+			DatePublished: work.Created().Format(time.RFC3339),
+		}
+		if thumbnail := g.thumbnailURL(work); thumbnail != "" {
+			item.Image = thumbnail
+			item.Attachments = []jsonFeedAttachment{{URL: thumbnail, MimeType: mimeTypeOf(thumbnail)}}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return feed
+}
+
+// updated returns the date of the newest work of the feed.
+func (f Feed) updated() time.Time {
+	if len(f.Works) == 0 {
+		return time.Unix(0, 0).UTC()
+	}
+	return f.Works[0].Created()
+}
+
+// feedHomePageURL returns the URL of the home page in the feed's language.
+func (g *Builder) feedHomePageURL(f Feed) string {
+	url, _ := g.PublicURLOf(f.Language, filepath.Join(g.OutputDirectory, strings.ReplaceAll(g.Configuration.Development.OutputTo.Translated, "<language>", f.Language), "index.html"))
+	return url
+}
+
+// workEntryID returns a permanent identifier for the work's feed entry: its page's URL, or a URN when it has no page.
+func workEntryID(work WorkOneLang, url string) string {
+	if url != "" {
+		return url
+	}
+	return "urn:ortfomk:work:" + work.ID
+}
+
+// thumbnailURL returns the URL of the largest thumbnail of the work's first (or designated) media.
+func (g *Builder) thumbnailURL(work WorkOneLang) string {
+	key := ""
+	for _, media := range work.Media {
+		if work.Metadata.Thumbnail == "" || media.Source == work.Metadata.Thumbnail {
+			key = media.Path
+			break
+		}
+	}
+	var largest uint16
+	for resolution := range work.Metadata.Thumbnails[key] {
+		if resolution > largest {
+			largest = resolution
+		}
+	}
+	if largest == 0 {
+		return ""
+	}
+	thumbnail := strings.TrimPrefix(filepath.ToSlash(work.Metadata.Thumbnails[key][largest]), "dist/media/")
+	return joinURL(g.Configuration.Production.AvailableAt.Media, thumbnail)
+}
+
+func mimeTypeOf(url string) string {
+	if mimeType := mime.TypeByExtension(filepath.Ext(url)); mimeType != "" {
+		return mimeType
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,57 @@
+package ortfomk
+
+import (
+	"encoding/xml"
+	"path/filepath"
+	"testing"
+
+	ortfodb "github.com/ortfo/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeds(t *testing.T) {
+	defer SetGlobalData(g)
+	config := DefaultConfiguration()
+	config.Languages = []string{"en"}
+	config.Production.AvailableAt = OutputTemplates{Translated: "https://<language>.example.com/", Rest: "https://example.com/", Media: "https://media.example.com/"}
+	config.Feeds.Title = map[string]string{"en": "Works"}
+	config.Feeds.Tags = []string{"design"}
+	dist := t.TempDir()
+	SetGlobalData(&GlobalData{Configuration: config, OutputDirectory: dist})
+
+	work := func(id string, created string, tags ...string) Work {
+		w := Work{Metadata: WorkMetadata{Created: created, Tags: tags, Thumbnails: map[string]map[uint16]string{
+			"cover.png": {400: "dist/media/" + id + "/cover@400.webp", 1000: "dist/media/" + id + "/cover@1000.webp"},
+		}}}
+		w.ID = id
+		w.Title = map[string]string{"en": id}
+		w.Media = map[string][]ortfodb.Media{"en": {{Source: "cover.png", Path: "cover.png"}}}
+		return w
+	}
+	SetDatabaseOnGlobalData(Database{
+		Works: []Work{work("older", "2020-01-01", "design"), work("newer", "2021-06-01"), work("undated", "")},
+		Tags:  []Tag{{Singular: "design", Plural: "designs"}},
+	})
+	g.addToSitemap(filepath.Join("src", "works", ":work.pug"), &Hydration{language: "en", work: g.Works[1]}, filepath.Join(dist, "en", "works", "newer.html"))
+
+	feeds := Feeds()
+	assert.Len(t, feeds, 2)
+	assert.Equal(t, filepath.Join(dist, "en", "feed.atom"), feeds[0].OutPath)
+	assert.Equal(t, []string{"newer", "older"}, []string{feeds[0].Works[0].ID, feeds[0].Works[1].ID})
+	assert.Equal(t, filepath.Join(dist, "en", "feeds", "tags", "designs.atom"), feeds[1].OutPath)
+	assert.Len(t, feeds[1].Works, 1)
+
+	atom := g.atomDocument(feeds[0])
+	assert.Equal(t, "https://en.example.com/feed.atom", atom.ID)
+	assert.Equal(t, "https://en.example.com/works/newer.html", atom.Entries[0].ID)
+	assert.Equal(t, "urn:ortfomk:work:older", atom.Entries[1].ID)
+	assert.Contains(t, atom.Entries[0].Links, atomLink{Rel: "enclosure", Href: "https://media.example.com/newer/cover@1000.webp", Type: "image/webp"})
+	encoded, err := xml.Marshal(atom)
+	assert.NoError(t, err)
+	assert.Contains(t, string(encoded), `<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">`)
+
+	jsonFeed := g.jsonFeedDocument(feeds[0])
+	assert.Equal(t, "https://en.example.com/feed.json", jsonFeed.FeedURL)
+	assert.Equal(t, "https://en.example.com/works/newer.html", jsonFeed.Items[0].URL)
+	assert.Equal(t, "https://media.example.com/newer/cover@1000.webp", jsonFeed.Items[0].Image)
+}
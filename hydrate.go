@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"fmt"
 	"os"
-	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
@@ -49,20 +48,29 @@ func (h *Hydration) IsCollection() bool {
 	return h.collection.ID != ""
 }
 
-// Name returns the identifier of the object in the hydration,
+// ObjectID returns the identifier of the object in the hydration,
 // and defaults to the empty string if the current hydration is empty
-func (h *Hydration) Name() string {
-	if h.IsWork() {
-		return h.work.ID + "@" + h.language
-	}
-	if h.IsCollection() {
-		return h.collection.ID + "@" + h.language
+func (h *Hydration) ObjectID() string {
+	switch {
+	case h.IsWork():
+		return h.work.ID
+	case h.IsCollection():
+		return h.collection.ID
+	case h.IsTag():
+		return h.tag.URLName()
+	case h.IsTech():
+		return h.tech.URLName
+	case h.IsSite():
+		return h.site.Name
 	}
-	if h.IsTag() {
-		return h.tag.URLName() + "@" + h.language
-	}
-	if h.IsTech() {
-		return h.tech.URLName + "@" + h.language
+	return ""
+}
+
+// Name returns the identifier of the object in the hydration followed by the language, as in "id@language",
+// and only the language if the current hydration is empty
+func (h *Hydration) Name() string {
+	if id := h.ObjectID(); id != "" {
+		return id + "@" + h.language
 	}
 	return h.language
 }
@@ -77,12 +85,12 @@ func BuildingForProduction() bool {
 }
 
 // PrintTemplateErrorMessage prints a nice error message with a preview of the code where the error occured
-func PrintTemplateErrorMessage(whileDoing string, templateName string, templateContent string, err error, templateLanguage string) {
+func (g *Builder) PrintTemplateErrorMessage(whileDoing string, templateName string, templateContent string, err error, templateLanguage string) {
 	// TODO when error occurs in a subtemplate, show code snippet from the innermost subtemplate instead of the outermost
 	lineIndexPattern := regexp.MustCompile(`:(\d+)`)
 	listIndices := lineIndexPattern.FindStringSubmatch(err.Error())
 	if listIndices == nil {
-		LogError("While %s %s: %s", whileDoing, templateName, err.Error())
+		g.LogError("While %s %s: %s", whileDoing, templateName, err.Error())
 		return
 	}
 	lineIndex64, _ := strconv.ParseInt(listIndices[1], 10, 64)
@@ -109,48 +117,72 @@ func PrintTemplateErrorMessage(whileDoing string, templateName string, templateC
 		}
 		message += fmt.Sprintf("%d %s\n", lineIndexOffset+i+1, line)
 	}
-	LogError(message)
+	g.LogError(message)
+}
+
+// TemplateError is returned by RunTemplate when executing a template throws an error.
+type TemplateError struct {
+	*v8.JSError
+	// CodeSnippet is an excerpt of the generated JavaScript file around the error's location.
+	CodeSnippet string
+	// PugFile and PugLine locate the error in the pug template (or in one of its includes).
+	// PugFile is empty if the error could not be traced back to a pug template.
+	PugFile string
+	PugLine int
+	// PugSnippet is an excerpt of the pug template around PugLine.
+	PugSnippet string
 }
 
-// CompileTemplate compiles a pug template using the CLI tool pug.
-func CompileTemplate(templateName string, templateContent []byte) ([]byte, error) {
-	command := exec.Command("pug", "--client", "--path", templateName)
-	LogDebug("compiling template: running %s", command)
-	command.Stdin = bytes.NewReader(templateContent)
-	command.Stderr = os.Stderr
+func (e *TemplateError) Error() string {
+	if e.PugFile != "" {
+		return fmt.Sprintf("while running template at %s: %s\n%s\nStack trace:\n%s", e.PugLocation(), e.JSError, e.PugSnippet, e.StackTrace)
+	}
+	return fmt.Sprintf("while running template: %s\n%s\nStack trace:\n%s", e.JSError, e.CodeSnippet, e.StackTrace)
+}
 
-	return command.Output()
+func (e *TemplateError) Unwrap() error {
+	return e.JSError
 }
 
 // RunTemplate parses a given (HTML) template.
-func RunTemplate(javascriptRuntime *v8.Isolate, hydration *Hydration, templateName string, compiledTemplate []byte) (string, error) {
-	compiledJSFile, err := GenerateJSFile(hydration, templateName, string(compiledTemplate))
-	if os.Getenv("DEBUG") == "1" {
-		os.WriteFile(templateName+"."+hydration.Name()+".js", []byte(compiledJSFile), 0644)
-	}
+func (g *Builder) RunTemplate(javascriptRuntime *JSRuntime, hydration *Hydration, templateName string, compiledTemplate []byte) (string, error) {
+	compiledJSFile, err := g.GenerateJSFile(hydration, templateName, string(compiledTemplate))
 	if err != nil {
 		return "", fmt.Errorf("while generating template: %w", err)
 	}
+	return RunJSFile(javascriptRuntime, compiledJSFile, templateName, hydration)
+}
+
+// RunJSFile runs a JavaScript file generated by GenerateJSFile in the given runtime and returns the rendered page.
+func RunJSFile(javascriptRuntime *JSRuntime, compiledJSFile GeneratedJSFile, templateName string, hydration *Hydration) (string, error) {
+	if os.Getenv("DEBUG") == "1" {
+		os.WriteFile(templateName+"."+hydration.Name()+".js", []byte(compiledJSFile.Content), 0644)
+	}
 
+	ctx, err := javascriptRuntime.contextFor(compiledJSFile)
+	if err != nil {
+		return "", fmt.Errorf("while setting up the JavaScript context: %w", err)
+	}
 	LogDebug("executing template")
-	ctx := v8.NewContext(javascriptRuntime)
-	jsValue, err := ctx.RunScript(compiledJSFile, templateName+".js")
+	jsValue, err := ctx.run(compiledJSFile, templateName+".js")
 	LogDebug("finished executing")
 	if err, ok := err.(*v8.JSError); ok {
 		line, column := lineAndColumn(err)
-		codeSpinnet := codeSpinnetAround(compiledJSFile, line, column)
-		return "", fmt.Errorf("while running template: %w\n%s\nStack trace:\n%s", err, codeSpinnet, err.StackTrace)
+		templateErr := &TemplateError{JSError: err, CodeSnippet: codeSpinnetAround(compiledJSFile.Content, line, column)}
+		templateErr.locatePugError(compiledJSFile, templateName+".js")
+		return "", templateErr
 	}
 	return jsValue.String(), nil
 }
 
+// lineAndColumn extracts the position of a JavaScript error from its location, which is of the form file:line:column.
 func lineAndColumn(err *v8.JSError) (line uint64, column uint64) {
 	parts := strings.Split(err.Location, ":")
 	for i, part := range parts {
 		var parseErr error
-		if i == len(parts)-1 {
+		if i == len(parts)-2 {
 			line, parseErr = strconv.ParseUint(part, 10, 64)
-		} else if i == len(parts)-2 {
+		} else if i == len(parts)-1 {
 			column, parseErr = strconv.ParseUint(part, 10, 64)
 		}
 		if parseErr != nil {
@@ -162,14 +194,40 @@ func lineAndColumn(err *v8.JSError) (line uint64, column uint64) {
 
 // TranslateHydrated translates an hydrated HTML page, removing i18n tags and attributes
 // and replacing translatable content with their translations
-func (t TranslationsOneLang) TranslateHydrated(content string) string {
-	content = t.TranslateTranslationStrings(content)
+func (t *TranslationsOneLang) TranslateHydrated(content string) string {
+	translated, _ := t.TranslateHydratedPage(content)
+	return translated
+}
+
+// TranslateHydratedPage is like TranslateHydrated, but also returns which messages were used to translate the page.
+// Pages that can't be translated, e.g. because they contain invalid translation strings, are returned empty.
+func (t *TranslationsOneLang) TranslateHydratedPage(content string) (string, TranslationUsage) {
+	translated, usage, _ := t.translateHydratedPage(content, t.sourceLanguage)
+	return translated, usage
+}
+
+// TranslateHydratedPage translates an hydrated HTML page to language, like TranslationsOneLang.TranslateHydrated,
+// and returns which messages were used to translate the page.
+func (g *Builder) TranslateHydratedPage(language string, content string) (string, TranslationUsage) {
+	translated, usage, err := g.Translations[language].translateHydratedPage(content, g.Configuration.SourceLanguage)
+	if err != nil {
+		g.LogError("An error occured while translating the hydrated HTML: %s", err)
+	}
+	return translated, usage
+}
+
+// translateHydratedPage translates an hydrated HTML page with the catalog, see TranslationsOneLang.translate.
+func (t *TranslationsOneLang) translateHydratedPage(content string, sourceLanguage string) (string, TranslationUsage, error) {
+	usage := TranslationUsage{}
+	content, err := t.translateTranslationStrings(content, &usage)
+	if err != nil {
+		return "", usage, err
+	}
 	parsedContent, err := html.Parse(strings.NewReader(content))
 	if err != nil {
-		LogError("An error occured while parsing the hydrated HTML for translation: %s", err)
-		return ""
+		return "", usage, fmt.Errorf("while parsing the hydrated HTML: %w", err)
 	}
-	return Translate(t.language, parsedContent)
+	return t.translate(parsedContent, sourceLanguage, &usage), usage, nil
 }
 
 // NameOfTemplate returns the name given to a template that is applied to multiple objects, e.g. :work.pug<portfolio>.
@@ -188,13 +246,7 @@ func (h *Hydration) WriteDistFile(fileName string, content string, language stri
 		LogError("Invalid path: %s", err)
 		return ""
 	}
-	distFile, err := os.Create(distFilePath)
-	if err != nil {
-		LogError("Could not create the destination file %s: %s ", distFilePath, err)
-		return ""
-	}
-	defer distFile.Close()
-	_, err = distFile.WriteString(content)
+	err = writeFileAtomically(distFilePath, []byte(content), 0o644)
 	if err != nil {
 		LogError("Could not write to the destination file %s: %s", distFilePath, err)
 		return ""
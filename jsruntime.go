@@ -0,0 +1,293 @@
+package ortfomk
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	v8 "rogchap.com/v8go"
+)
+
+// SharedData is data that is the same for every page in a language, such as all_works.
+// It is serialized once per language and build (see sharedDataOf),
+// and evaluated once per JavaScript context instead of once per page (see JSRuntime).
+type SharedData struct {
+	Name string
+	// Value, as JSON
+	JSON string
+	// Hash of JSON, to tell apart versions of the data across builds
+	Hash string
+}
+
+// sharedDataCache holds the shared data serialized during the current build, by language and name.
+type sharedDataCache struct {
+	mu      sync.Mutex
+	entries map[string]*SharedData
+}
+
+// resetSharedData forgets serialized shared data, before starting a new full build.
+func (g *Builder) resetSharedData() {
+	g.sharedDataCache.mu.Lock()
+	defer g.sharedDataCache.mu.Unlock()
+	g.sharedDataCache.entries = make(map[string]*SharedData)
+}
+
+// sharedDataOf returns the shared data called name in language, computing and serializing it with compute the first time.
+func (g *Builder) sharedDataOf(language string, name string, compute func() (interface{}, error)) (*SharedData, error) {
+	g.sharedDataCache.mu.Lock()
+	defer g.sharedDataCache.mu.Unlock()
+	key := language + "\x00" + name
+	if data, ok := g.sharedDataCache.entries[key]; ok {
+		return data, nil
+	}
+	value, err := compute()
+	if err != nil {
+		return nil, err
+	}
+	jsoned, err := marshalTemplateData(value)
+	if err != nil {
+		return nil, fmt.Errorf("while converting %s JSON: %w", name, err)
+	}
+	data := &SharedData{Name: name, JSON: jsoned, Hash: hashString(jsoned)}
+	if g.sharedDataCache.entries == nil {
+		g.sharedDataCache.entries = make(map[string]*SharedData)
+	}
+	g.sharedDataCache.entries[key] = data
+	return data, nil
+}
+
+// jsContextSetup is run once per context, after the prelude and before the static template functions.
+//
+// Shared data is evaluated once, and pages only see it through views (see __ortfomk_view):
+// changes made by a page, e.g. sorting all_works in place, go to copies of the changed objects that only this page sees.
+// Objects are copied when first changed, and only the ones that are changed.
+const jsContextSetup = `
+globalThis.__ortfomk_shared = {}
+// Objects evaluated from shared data, which pages can't change
+const __ortfomk_shared_objects = new WeakSet()
+// Maps shared objects to their copy and their view, for the page being rendered
+let __ortfomk_page = { copies: new WeakMap(), views: new WeakMap() }
+
+globalThis.__ortfomk_define_shared = (name, json) => {
+	__ortfomk_shared[name] = JSON.parse(json, (key, value) => {
+		if (typeof value === "object" && value !== null) __ortfomk_shared_objects.add(value)
+		return value
+	})
+}
+
+globalThis.__ortfomk_start_page = () => {
+	__ortfomk_page = { copies: new WeakMap(), views: new WeakMap() }
+}
+
+globalThis.__ortfomk_view = value => {
+	if (!__ortfomk_shared_objects.has(value)) return value
+	const page = __ortfomk_page
+	if (page.views.has(value)) return page.views.get(value)
+	const current = () => page.copies.get(value) || value
+	const writable = () => {
+		if (!page.copies.has(value)) page.copies.set(value, Array.isArray(value) ? [...value] : { ...value })
+		return page.copies.get(value)
+	}
+	const view = new Proxy(value, {
+		get: (_, key) => __ortfomk_view(Reflect.get(current(), key)),
+		has: (_, key) => Reflect.has(current(), key),
+		ownKeys: () => Reflect.ownKeys(current()),
+		getOwnPropertyDescriptor: (_, key) => {
+			const descriptor = Reflect.getOwnPropertyDescriptor(current(), key)
+			if (descriptor && "value" in descriptor) descriptor.value = __ortfomk_view(descriptor.value)
+			return descriptor
+		},
+		set: (_, key, newValue) => Reflect.set(writable(), key, newValue),
+		deleteProperty: (_, key) => Reflect.deleteProperty(writable(), key),
+		defineProperty: (_, key, descriptor) => Reflect.defineProperty(writable(), key, descriptor),
+	})
+	page.views.set(value, view)
+	return view
+}
+`
+
+var staticTemplateFunctionsCodeCache = struct {
+	mu   sync.Mutex
+	data *v8.CompilerCachedData
+}{}
+
+// JSRuntime renders pages in a V8 isolate.
+// Isolates can't be used by multiple goroutines at once: get a runtime from a JSRuntimePool, and put it back when done.
+//
+// Instead of rendering each page in a fresh context, a runtime keeps one context per language (and prelude, see GenerateJSFile),
+// in which the static template functions are run once and each piece of SharedData is evaluated once.
+// Only the page's own data (CurrentWork, current_path, etc.) is evaluated for each page.
+// Pages get views of the shared data (see jsContextSetup and GenerateJSFile),
+// so that pages can change it (e.g. sort all_works) without changing what other pages see.
+type JSRuntime struct {
+	isolate                 *v8.Isolate
+	staticTemplateFunctions *v8.UnboundScript
+	contexts                map[string]*jsContext
+}
+
+type jsContext struct {
+	context *v8.Context
+	// Maps names of the shared data defined in the context to their hash
+	sharedData map[string]string
+	// Globals defined by the last page rendered in the context
+	pageGlobals []string
+}
+
+// NewJSRuntime creates a runtime with its own V8 isolate.
+func NewJSRuntime() *JSRuntime {
+	return &JSRuntime{isolate: v8.NewIsolate(), contexts: make(map[string]*jsContext)}
+}
+
+// Dispose frees the runtime's isolate. The runtime can't be used afterwards.
+func (r *JSRuntime) Dispose() {
+	for _, context := range r.contexts {
+		context.context.Close()
+	}
+	r.contexts = nil
+	r.isolate.Dispose()
+}
+
+// compileStaticTemplateFunctions compiles template.js, re-using the code cache of other isolates.
+func (r *JSRuntime) compileStaticTemplateFunctions() (*v8.UnboundScript, error) {
+	if r.staticTemplateFunctions != nil {
+		return r.staticTemplateFunctions, nil
+	}
+	staticTemplateFunctionsCodeCache.mu.Lock()
+	defer staticTemplateFunctionsCodeCache.mu.Unlock()
+	options := v8.CompileOptions{}
+	if cached := staticTemplateFunctionsCodeCache.data; cached != nil && len(cached.Bytes) > 0 {
+		options.CachedData = &v8.CompilerCachedData{Bytes: cached.Bytes}
+	}
+	script, err := r.isolate.CompileUnboundScript(staticTemplateFunctions, "template.js", options)
+	if err != nil {
+		return nil, err
+	}
+	if options.CachedData == nil || options.CachedData.Rejected {
+		staticTemplateFunctionsCodeCache.data = script.CreateCodeCache()
+	}
+	r.staticTemplateFunctions = script
+	return script, nil
+}
+
+// contextFor returns the context to render file in, setting it up if needed, with the file's shared data defined.
+func (r *JSRuntime) contextFor(file GeneratedJSFile) (*jsContext, error) {
+	key := file.Language + "\x00" + file.Prelude
+	context, ok := r.contexts[key]
+	if !ok {
+		staticFunctions, err := r.compileStaticTemplateFunctions()
+		if err != nil {
+			return nil, fmt.Errorf("while compiling static template functions: %w", err)
+		}
+		context = &jsContext{context: v8.NewContext(r.isolate), sharedData: make(map[string]string)}
+		if _, err := context.context.RunScript(file.Prelude, "prelude.js"); err != nil {
+			context.context.Close()
+			return nil, fmt.Errorf("while running prelude: %w", err)
+		}
+		if _, err := context.context.RunScript(jsContextSetup, "setup.js"); err != nil {
+			context.context.Close()
+			return nil, fmt.Errorf("while setting up context: %w", err)
+		}
+		if _, err := staticFunctions.Run(context.context); err != nil {
+			context.context.Close()
+			return nil, fmt.Errorf("while running static template functions: %w", err)
+		}
+		r.contexts[key] = context
+	}
+
+	for _, data := range file.SharedData {
+		if context.sharedData[data.Name] == data.Hash {
+			continue
+		}
+		if err := context.defineSharedData(data); err != nil {
+			return nil, fmt.Errorf("while defining %s: %w", data.Name, err)
+		}
+		context.sharedData[data.Name] = data.Hash
+	}
+	return context, nil
+}
+
+// defineSharedData evaluates data in the context, replacing any previous version of it.
+func (c *jsContext) defineSharedData(data *SharedData) error {
+	name, err := v8.NewValue(c.context.Isolate(), data.Name)
+	if err != nil {
+		return err
+	}
+	jsoned, err := v8.NewValue(c.context.Isolate(), data.JSON)
+	if err != nil {
+		return err
+	}
+	define, err := c.context.Global().Get("__ortfomk_define_shared")
+	if err != nil {
+		return err
+	}
+	defineFunction, err := define.AsFunction()
+	if err != nil {
+		return err
+	}
+	_, err = defineFunction.Call(c.context.Global(), name, jsoned)
+	return err
+}
+
+// run runs the file in the context, after removing the globals the previous page defined,
+// and forgetting the changes it made to shared data.
+func (c *jsContext) run(file GeneratedJSFile, scriptName string) (*v8.Value, error) {
+	cleanup := []string{"__ortfomk_start_page();"}
+	for _, name := range c.pageGlobals {
+		cleanup = append(cleanup, "delete globalThis["+strconv.Quote(name)+"];")
+	}
+	if _, err := c.context.RunScript(strings.Join(cleanup, "\n"), "cleanup.js"); err != nil {
+		return nil, fmt.Errorf("while removing globals of the previous page: %w", err)
+	}
+	c.pageGlobals = file.Globals
+	return c.context.RunScript(file.Content, scriptName)
+}
+
+// JSRuntimePool holds up to a given number of JSRuntimes, created when first needed.
+// Size it to the number of goroutines that render pages at the same time.
+type JSRuntimePool struct {
+	mu        sync.Mutex
+	size      int
+	created   int
+	available chan *JSRuntime
+}
+
+// NewJSRuntimePool creates a pool of up to size runtimes. A size of 0 or less means runtime.NumCPU().
+func NewJSRuntimePool(size int) *JSRuntimePool {
+	if size <= 0 {
+		size = runtime.NumCPU()
+	}
+	return &JSRuntimePool{size: size, available: make(chan *JSRuntime, size)}
+}
+
+// Get returns an available runtime, creating one if the pool is not full, or waiting for one to be put back otherwise.
+func (p *JSRuntimePool) Get() *JSRuntime {
+	select {
+	case runtime := <-p.available:
+		return runtime
+	default:
+	}
+	p.mu.Lock()
+	if p.created < p.size {
+		p.created++
+		p.mu.Unlock()
+		return NewJSRuntime()
+	}
+	p.mu.Unlock()
+	return <-p.available
+}
+
+// Put gives back a runtime obtained with Get.
+func (p *JSRuntimePool) Put(runtime *JSRuntime) {
+	p.available <- runtime
+}
+
+// Dispose disposes every runtime of the pool. It must be called once all runtimes were put back.
+func (p *JSRuntimePool) Dispose() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for ; p.created > 0; p.created-- {
+		(<-p.available).Dispose()
+	}
+}
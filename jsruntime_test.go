@@ -0,0 +1,87 @@
+package ortfomk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSRuntimeReusesContexts(t *testing.T) {
+	defer SetGlobalData(g)
+	SetGlobalData(&GlobalData{AdditionalData: map[string]interface{}{"numbers": []int{3, 1, 2}}})
+	runtime := NewJSRuntime()
+	defer runtime.Dispose()
+
+	render := func(hydration *Hydration, compiledTemplate string) (string, error) {
+		file, err := GenerateJSFile(hydration, "page.pug", compiledTemplate)
+		if err != nil {
+			return "", err
+		}
+		return RunJSFile(runtime, file, "page.pug", hydration)
+	}
+
+	tag := &Hydration{language: "en", tag: Tag{Singular: "poster", Plural: "posters"}}
+	content, err := render(tag, `function template(locals) { return CurrentTag.Plural + numbers.join(",") }`)
+	assert.NoError(t, err)
+	assert.Equal(t, "posters3,1,2", content)
+
+	page := &Hydration{language: "en"}
+	content, err = render(page, `function template(locals) { return typeof CurrentTag + " " + numbers.join(",") }`)
+	assert.NoError(t, err)
+	assert.Equal(t, "undefined 3,1,2", content, "globals of the previous page are removed")
+
+	content, err = render(page, `function template(locals) { return numbers.sort().join(",") }`)
+	assert.NoError(t, err)
+	assert.Equal(t, "1,2,3", content)
+	content, err = render(page, `function template(locals) { return numbers.join(",") }`)
+	assert.NoError(t, err)
+	assert.Equal(t, "3,1,2", content, "pages changing shared data don't change what other pages see")
+	assert.Len(t, runtime.contexts, 1)
+
+	content, err = render(&Hydration{language: "fr"}, `function template(locals) { return MostRecentsFirst([]).length + current_language }`)
+	assert.NoError(t, err)
+	assert.Equal(t, "0fr", content)
+	assert.Len(t, runtime.contexts, 2, "each language has its own context")
+
+	SetGlobalData(&GlobalData{AdditionalData: map[string]interface{}{"current_language": "overridden"}})
+	content, err = render(page, `function template(locals) { return current_language }`)
+	assert.NoError(t, err)
+	assert.Equal(t, "overridden", content, "additional data overrides data with the same name")
+}
+
+func TestSharedDataViews(t *testing.T) {
+	defer SetGlobalData(g)
+	SetGlobalData(&GlobalData{AdditionalData: map[string]interface{}{
+		"works": []map[string]interface{}{{"ID": "b", "Tags": []string{"x"}}, {"ID": "a", "Tags": []string{}}},
+	}})
+	runtime := NewJSRuntime()
+	defer runtime.Dispose()
+
+	render := func(compiledTemplate string) string {
+		file, err := GenerateJSFile(&Hydration{language: "en"}, "page.pug", compiledTemplate)
+		assert.NoError(t, err)
+		content, err := RunJSFile(runtime, file, "page.pug", &Hydration{language: "en"})
+		assert.NoError(t, err)
+		return content
+	}
+
+	assert.Equal(t, "a,b true", render(`function template(locals) {
+		const sorted = works.sort((a, b) => a.ID < b.ID ? -1 : 1)
+		works[0].Tags.push("y")
+		works[1].ID += "!"
+		return sorted.map(w => w.ID).join(",").replace("!", "") + " " + (sorted === works)
+	}`))
+	assert.Equal(t, `[{"ID":"b","Tags":["x"]},{"ID":"a","Tags":[]}]`, render(`function template(locals) { return JSON.stringify(works) }`), "changes of previous pages are forgotten")
+	assert.Equal(t, `b,x a,`, render(`function template(locals) { return works.map(w => ({ ...w })).map(w => w.ID + "," + w.Tags.join(",")).join(" ") }`))
+}
+
+func TestJSRuntimePool(t *testing.T) {
+	pool := NewJSRuntimePool(2)
+	first, second := pool.Get(), pool.Get()
+	assert.NotSame(t, first, second)
+	pool.Put(first)
+	assert.Same(t, first, pool.Get(), "runtimes are re-used once the pool is full")
+	pool.Put(first)
+	pool.Put(second)
+	pool.Dispose()
+}
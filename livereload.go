@@ -0,0 +1,134 @@
+package ortfomk
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+//go:embed livereload.js
+var liveReloadScript string
+
+// LiveReloadEndpoint is the path on the development server on which reload events are streamed.
+const LiveReloadEndpoint = "/__ortfomk/livereload"
+
+// liveReloader broadcasts reload events to every browser tab connected to the development server,
+// using Server-Sent Events.
+type liveReloader struct {
+	mu          sync.Mutex
+	subscribers map[chan []string]bool
+	// language in which the development server serves translated pages
+	language string
+}
+
+func (l *liveReloader) subscribe() chan []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	events := make(chan []string, 1)
+	if l.subscribers == nil {
+		l.subscribers = make(map[chan []string]bool)
+	}
+	l.subscribers[events] = true
+	return events
+}
+
+func (l *liveReloader) unsubscribe(events chan []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.subscribers, events)
+}
+
+// broadcast sends the given URL paths to every subscriber.
+// Subscribers that still have a pending event are skipped, since they're about to reload anyway.
+func (l *liveReloader) broadcast(urlPaths []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for events := range l.subscribers {
+		select {
+		case events <- urlPaths:
+		default:
+		}
+	}
+}
+
+func (l *liveReloader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	events := l.subscribe()
+	defer l.unsubscribe(events)
+	for {
+		select {
+		case urlPaths := <-events:
+			payload, err := json.Marshal(urlPaths)
+			if err != nil {
+				LogError("while encoding live reload event: %s", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: reload\ndata: %s\n\n", payload)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// TriggerLiveReload tells browser tabs showing one of the given output files to reload.
+// If outputPaths is empty, every tab reloads.
+func (g *Builder) TriggerLiveReload(outputPaths []string) {
+	g.reloader.mu.Lock()
+	language := g.reloader.language
+	g.reloader.mu.Unlock()
+	urlPaths := make([]string, 0, len(outputPaths))
+	for _, outputPath := range outputPaths {
+		urlPaths = append(urlPaths, g.urlPathsOfOutputFile(language, outputPath)...)
+	}
+	if len(outputPaths) > 0 && len(urlPaths) == 0 {
+		return
+	}
+	g.LogDebug("sending live reload event for %v", urlPaths)
+	g.reloader.broadcast(urlPaths)
+}
+
+// urlPathsOfOutputFile returns the URL paths at which the development server serves the given output file.
+// This is the reverse of what devserver.Open does.
+func (g *Builder) urlPathsOfOutputFile(language string, outputPath string) (urlPaths []string) {
+	relativePath, err := filepath.Rel(g.OutputDirectory, outputPath)
+	if err != nil {
+		return
+	}
+	relativePath = filepath.ToSlash(relativePath)
+	for _, prefix := range []string{
+		strings.ReplaceAll(g.Configuration.Development.OutputTo.Translated, "<language>", language),
+		g.Configuration.Development.OutputTo.Rest,
+	} {
+		prefix = strings.Trim(filepath.ToSlash(prefix), "/")
+		if prefix == "" {
+			urlPaths = append(urlPaths, "/"+relativePath)
+		} else if strings.HasPrefix(relativePath, prefix+"/") {
+			urlPaths = append(urlPaths, "/"+strings.TrimPrefix(relativePath, prefix+"/"))
+		}
+	}
+	return
+}
+
+// injectLiveReloadScript adds the live reload client script at the end of the given HTML document's body.
+func injectLiveReloadScript(html []byte) []byte {
+	script := fmt.Sprintf("<script>(%s)(%q)</script>", liveReloadScript, LiveReloadEndpoint)
+	document := string(html)
+	if bodyEnd := strings.LastIndex(document, "</body>"); bodyEnd >= 0 {
+		return []byte(document[:bodyEnd] + script + document[bodyEnd:])
+	}
+	return []byte(document + script)
+}
@@ -0,0 +1,19 @@
+// Injected into pages served by the development server,
+// called with the path of the live reload events endpoint.
+endpoint => {
+  // Compare paths without their .html extension, index page name or trailing slash
+  const normalize = path =>
+    path.replace(/\.html$/, "").replace(/(^|\/)index$/, "/").replace(/\/$/, "") ||
+    "/"
+
+  const events = new EventSource(endpoint)
+  events.addEventListener("reload", event => {
+    const rebuilt = JSON.parse(event.data)
+    if (
+      rebuilt.length === 0 ||
+      rebuilt.map(normalize).includes(normalize(location.pathname))
+    ) {
+      location.reload()
+    }
+  })
+}
@@ -0,0 +1,25 @@
+package ortfomk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLPathsOfOutputFile(t *testing.T) {
+	defer SetGlobalData(g)
+	SetGlobalData(&GlobalData{OutputDirectory: "dist", Configuration: DefaultConfiguration()})
+	assert.Equal(t, []string{"/about.html", "/en/about.html"}, g.urlPathsOfOutputFile("en", "dist/en/about.html"))
+	assert.Equal(t, []string{"/fr/about.html"}, g.urlPathsOfOutputFile("en", "dist/fr/about.html"))
+
+	g.Configuration.Development.OutputTo.Rest = "assets/"
+	assert.Equal(t, []string{"/style.css"}, g.urlPathsOfOutputFile("en", "dist/assets/style.css"))
+	assert.Empty(t, g.urlPathsOfOutputFile("en", "dist/fr/about.html"))
+}
+
+func TestInjectLiveReloadScript(t *testing.T) {
+	injected := string(injectLiveReloadScript([]byte("<html><body><p>hi</p></body></html>")))
+	assert.Contains(t, injected, "<p>hi</p><script>")
+	assert.Contains(t, injected, "</script></body></html>")
+	assert.Contains(t, injected, `("/__ortfomk/livereload")`)
+}
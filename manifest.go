@@ -0,0 +1,176 @@
+package ortfomk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// BuildManifest records, for each output file, what it was built from.
+// It is stored in the cache directory between builds, so that pages whose inputs did not change are not rendered again.
+type BuildManifest struct {
+	mu sync.Mutex
+	// Pages built (or found up to date) since the last call to StartBuild
+	Pages map[string]BuildManifestEntry `json:"pages"`
+	// Pages from the previous build
+	previous map[string]BuildManifestEntry
+	rendered int
+	skipped  int
+}
+
+// BuildManifestEntry describes the inputs an output file was built from.
+type BuildManifestEntry struct {
+	// Template is the hash of the code that renders the page: compiled template, static template functions, etc.
+	Template string `json:"template"`
+	// Data is the hash of the data injected into the template: hydration, database, additional data, etc.
+	Data string `json:"data"`
+	// Translations lists the messages used while translating the rendered page.
+	Translations TranslationUsage `json:"translations"`
+	// TranslationsFingerprint hashes the translations of the messages used by the page, see TranslationsOneLang.FingerprintOf.
+	TranslationsFingerprint string `json:"translationsFingerprint,omitempty"`
+	// Links are the HTTP links the page contains, used to check for dead links.
+	Links []string `json:"links,omitempty"`
+	// Source is the template the page was built from, relative to the templates directory.
+	Source string `json:"source,omitempty"`
+}
+
+// NewBuildManifest creates an empty manifest.
+func NewBuildManifest() *BuildManifest {
+	return &BuildManifest{
+		Pages:    make(map[string]BuildManifestEntry),
+		previous: make(map[string]BuildManifestEntry),
+	}
+}
+
+// BuildManifestPath returns where the manifest for builds into outputDirectory is stored.
+// Each output directory has its own manifest, since what's up to date in one is not in another.
+func (g *Builder) BuildManifestPath(outputDirectory string) string {
+	absolute, err := filepath.Abs(outputDirectory)
+	if err != nil {
+		absolute = outputDirectory
+	}
+	return filepath.Join(g.Configuration.CacheDirectory, "builds", hashString(absolute)[:16]+".json")
+}
+
+// LoadBuildManifest reads the manifest at path. A missing file gives an empty manifest.
+func LoadBuildManifest(path string) (*BuildManifest, error) {
+	manifest := NewBuildManifest()
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, fmt.Errorf("while reading build manifest: %w", err)
+	}
+	if err := json.Unmarshal(raw, manifest); err != nil {
+		return NewBuildManifest(), fmt.Errorf("while parsing build manifest %s: %w", path, err)
+	}
+	if manifest.Pages == nil {
+		manifest.Pages = make(map[string]BuildManifestEntry)
+	}
+	return manifest, nil
+}
+
+// Save writes the manifest to path.
+func (m *BuildManifest) Save(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(path, raw, 0o644)
+}
+
+// StartBuild starts recording a new full build: pages recorded so far become the previous build's.
+func (m *BuildManifest) StartBuild() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for outPath, entry := range m.Pages {
+		m.previous[outPath] = entry
+	}
+	m.Pages = make(map[string]BuildManifestEntry)
+	m.rendered = 0
+	m.skipped = 0
+}
+
+// UpToDate returns the recorded entry for outPath if the page was built from the same inputs as the given ones,
+// none of the messages it used changed in translations, and its output file still exists.
+func (m *BuildManifest) UpToDate(outPath string, inputs BuildManifestEntry, translations *TranslationsOneLang) (BuildManifestEntry, bool) {
+	m.mu.Lock()
+	entry, recorded := m.Pages[outPath]
+	if !recorded {
+		entry, recorded = m.previous[outPath]
+	}
+	m.mu.Unlock()
+	if !recorded || entry.Template != inputs.Template || entry.Data != inputs.Data {
+		return BuildManifestEntry{}, false
+	}
+	if entry.TranslationsFingerprint != translations.FingerprintOf(entry.Translations) {
+		return BuildManifestEntry{}, false
+	}
+	if _, err := os.Stat(outPath); err != nil {
+		return BuildManifestEntry{}, false
+	}
+	return entry, true
+}
+
+// Record stores the entry for outPath. skipped tells whether the page was up to date instead of being rendered.
+func (m *BuildManifest) Record(outPath string, entry BuildManifestEntry, skipped bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Pages[outPath] = entry
+	if skipped {
+		m.skipped++
+	} else {
+		m.rendered++
+	}
+}
+
+// RecordOutput records a generated file that is not a page, such as a sitemap, so that it is known as generated by ortfomk.
+func (m *BuildManifest) RecordOutput(outPath string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Pages[outPath] = BuildManifestEntry{}
+}
+
+// Counts returns how many pages were rendered and how many were skipped because they were up to date, since the last StartBuild.
+func (m *BuildManifest) Counts() (rendered int, skipped int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rendered, m.skipped
+}
+
+// PageInputs hashes what the page rendered by the given generated file depends on.
+// Translations are not part of it: the messages a page uses are only known once it is rendered (see BuildManifest.UpToDate).
+func (g *Builder) PageInputs(file GeneratedJSFile) BuildManifestEntry {
+	code := strings.Builder{}
+	code.WriteString(file.Prelude + "\x00" + staticTemplateFunctions + "\x00")
+	data := ""
+	for _, section := range file.Sections {
+		if section.Name == SectionData {
+			data = file.Content[section.Start:section.End]
+		} else {
+			code.WriteString(file.Content[section.Start:section.End])
+		}
+	}
+	for _, shared := range file.SharedData {
+		data += "\x00" + shared.Name + ": " + shared.Hash
+	}
+	// Translating the rendered page also depends on which language is the source one.
+	code.WriteString("\x00source language: " + g.Configuration.SourceLanguage)
+	return BuildManifestEntry{
+		Template: hashString(code.String()),
+		Data:     hashString(data),
+	}
+}
+
+func hashString(s string) string {
+	hash := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,81 @@
+package ortfomk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	po "github.com/chai2010/gettext-go/po"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildManifestUpToDate(t *testing.T) {
+	defer SetGlobalData(g)
+	config := DefaultConfiguration()
+	config.CacheDirectory = t.TempDir()
+	SetGlobalData(&GlobalData{Configuration: config, OutputDirectory: "dist"})
+
+	outPath := filepath.Join(t.TempDir(), "index.html")
+	inputs := BuildManifestEntry{Template: "template", Data: "data"}
+	manifest := NewBuildManifest()
+	manifest.StartBuild()
+	manifest.Record(outPath, BuildManifestEntry{Template: "template", Data: "data", Links: []string{"https://example.com"}}, false)
+
+	_, upToDate := manifest.UpToDate(outPath, inputs, nil)
+	assert.False(t, upToDate, "output file does not exist yet")
+
+	os.WriteFile(outPath, []byte("<p>hi</p>"), 0644)
+	assert.NoError(t, manifest.Save(BuildManifestPath("dist")))
+	loaded, err := LoadBuildManifest(BuildManifestPath("dist"))
+	assert.NoError(t, err)
+	loaded.StartBuild()
+
+	entry, upToDate := loaded.UpToDate(outPath, inputs, nil)
+	assert.True(t, upToDate)
+	assert.Equal(t, []string{"https://example.com"}, entry.Links)
+
+	_, upToDate = loaded.UpToDate(outPath, BuildManifestEntry{Template: "template", Data: "other data"}, nil)
+	assert.False(t, upToDate)
+
+	loaded.Record(outPath, entry, true)
+	rendered, skipped := loaded.Counts()
+	assert.Equal(t, 0, rendered)
+	assert.Equal(t, 1, skipped)
+}
+
+func TestPageInputs(t *testing.T) {
+	defer SetGlobalData(g)
+	SetGlobalData(&GlobalData{Configuration: DefaultConfiguration()})
+
+	file := GeneratedJSFile{}
+	file.appendSection(SectionData, "const works = [];\n")
+	file.appendSection(SectionCompiledPugTemplate, "function template() {}\n")
+	otherData := GeneratedJSFile{}
+	otherData.appendSection(SectionData, "const works = [1];\n")
+	otherData.appendSection(SectionCompiledPugTemplate, "function template() {}\n")
+
+	inputs, other := PageInputs(file), PageInputs(otherData)
+	assert.Equal(t, inputs.Template, other.Template)
+	assert.NotEqual(t, inputs.Data, other.Data)
+}
+
+func TestBuildManifestUpToDateOnlyDependsOnUsedTranslations(t *testing.T) {
+	translations := &TranslationsOneLang{language: "fr", poFile: po.File{Messages: []po.Message{
+		{MsgId: "About", MsgStr: "À propos"},
+		{MsgId: "Contact", MsgStr: "Contact"},
+	}}}
+	outPath := filepath.Join(t.TempDir(), "about.html")
+	os.WriteFile(outPath, []byte("<p>À propos</p>"), 0644)
+	usage := TranslationUsage{Seen: []TranslationMessageRef{{ID: "About"}}}
+	manifest := NewBuildManifest()
+	manifest.Record(outPath, BuildManifestEntry{Template: "template", Data: "data", Translations: usage, TranslationsFingerprint: translations.FingerprintOf(usage)}, false)
+	inputs := BuildManifestEntry{Template: "template", Data: "data"}
+
+	translations.poFile.Messages[1].MsgStr = "Me contacter"
+	_, upToDate := manifest.UpToDate(outPath, inputs, translations)
+	assert.True(t, upToDate, "messages the page does not use changed")
+
+	translations.poFile.Messages[0].MsgStr = "Qui suis-je ?"
+	_, upToDate = manifest.UpToDate(outPath, inputs, translations)
+	assert.False(t, upToDate, "a message the page uses changed")
+}
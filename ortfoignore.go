@@ -11,7 +11,7 @@ import (
 // If no .ortfoignore file is found in currentDirectory, it will be searched for in currentDirectory's parent, recursively,
 // until the currentDirectory is the templatesDirectory. If currentDirectory is templatesDirectory, and no .ortfoignore file is found,
 // return (nil, nil). If templatesDirectory is not a parent of currentDirectory, an error is returned.
-func closestOrtfoignore(currentDirectory string) (gitignore.GitIgnore, error) {
+func (g *Builder) closestOrtfoignore(currentDirectory string) (gitignore.GitIgnore, error) {
 	relativeToTemplates, err := filepath.Rel(g.TemplatesDirectory, currentDirectory)
 	if err != nil {
 		return nil, errors.New("attempted to find ortfoignore from outside the templates directory")
@@ -21,7 +21,7 @@ func closestOrtfoignore(currentDirectory string) (gitignore.GitIgnore, error) {
 	_, err = os.Stat(ortfoignorePath)
 	if os.IsNotExist(err) {
 		if checkParent {
-			return closestOrtfoignore(filepath.Dir(currentDirectory))
+			return g.closestOrtfoignore(filepath.Dir(currentDirectory))
 		}
 
 		return nil, nil
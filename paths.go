@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/antonmedv/expr"
 	exprAST "github.com/antonmedv/expr/ast"
@@ -29,6 +30,30 @@ func PreprocessDynamicPathExpression(expression string) string {
 	return expression
 }
 
+// ExpressionCache holds compiled expressions by source. It can be used by multiple goroutines at once.
+type ExpressionCache struct {
+	mu       sync.Mutex
+	programs map[string]*exprVM.Program
+}
+
+// DynamicPathExpressionsCache caches compiled dynamic path expressions and work collection predicates.
+var DynamicPathExpressionsCache = &ExpressionCache{programs: make(map[string]*exprVM.Program)}
+
+// Get returns the compiled program of expression, if it was added.
+func (c *ExpressionCache) Get(expression string) (program *exprVM.Program, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	program, ok = c.programs[expression]
+	return
+}
+
+// Add stores the compiled program of expression.
+func (c *ExpressionCache) Add(expression string, program *exprVM.Program) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.programs[expression] = program
+}
+
 // EvaluateDynamicPathExpression evaluates a path expression (that does not contain the leading ":" or the surrounding "[" and "]"
 // and returns the evaluated expression, as a boolean (second return value) if the result is a boolean or as a string (first return value) if
 // the result is anything else (stringifying the type with "%s"). If the result is an empty string, it becomes indistinguishable from a false boolean result.
@@ -36,15 +61,15 @@ func PreprocessDynamicPathExpression(expression string) string {
 func EvaluateDynamicPathExpression(h *Hydration, expression string) (stringResult string, boolResult bool, err error) {
 	var compiledExpr *exprVM.Program
 	expression = PreprocessDynamicPathExpression(expression)
-	if cached, ok := DynamicPathExpressionsCache[expression]; ok {
+	if cached, ok := DynamicPathExpressionsCache.Get(expression); ok {
 		compiledExpr = cached
 	} else {
 		LogDebug("Compiling dynamic path expression %q", expression)
 		compiledExpr, err = expr.Compile(expression)
-		DynamicPathExpressionsCache[expression] = compiledExpr
-	}
-	if err != nil {
-		return "", false, fmt.Errorf("invalid dynamic path expression %q: %w", expression, err)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid dynamic path expression %q: %w", expression, err)
+		}
+		DynamicPathExpressionsCache.Add(expression, compiledExpr)
 	}
 	value, err := expr.Run(compiledExpr, map[string]interface{}{
 		"work":       h.work,
@@ -159,13 +184,30 @@ func EvaluateDynamicPath(h *Hydration, path string) (string, error) {
 	return leadingSlash + filepath.Join(evaluatedParts...), nil
 }
 
-// GetDistFilepath evaluates dynamic paths and replaces src/ with dist/.
+// distDirectory returns the directory pages are built to: the output directory, or dist/ if it is not set.
+func (g *Builder) distDirectory() string {
+	if g.OutputDirectory == "" {
+		return "dist"
+	}
+	return g.OutputDirectory
+}
+
+// relativeToDistDirectory returns outPath relative to the directory pages are built to (see distDirectory).
+func (g *Builder) relativeToDistDirectory(outPath string) string {
+	relative, err := filepath.Rel(g.distDirectory(), outPath)
+	if err != nil {
+		return outPath
+	}
+	return relative
+}
+
+// GetDistFilepath evaluates dynamic paths and replaces src/ with the output directory (dist/ by default).
 // An empty return value means the path shouldn't be rendered with this hydration.
-func (h *Hydration) GetDistFilepath(srcFilepath string) (string, error) {
+func (g *Builder) GetDistFilepath(h *Hydration, srcFilepath string) (string, error) {
 	// Turn into a dist/ path
-	outPath := filepath.Join("dist", GetPathRelativeToSrcDir(srcFilepath))
+	outPath := filepath.Join(g.distDirectory(), g.GetPathRelativeToSrcDir(srcFilepath))
 	outPath, err := EvaluateDynamicPath(h, outPath)
-	LogDebug("after evaluation, path is %q", outPath)
+	g.LogDebug("after evaluation, path is %q", outPath)
 	if err != nil {
 		return "", fmt.Errorf("couldn't evaluate dynamic path %q: %w", outPath, err)
 	}
@@ -7,11 +7,8 @@ import (
 	"github.com/SebastiaanKlippert/go-wkhtmltopdf"
 )
 
-func WritePDF(html string, to string) error {
-	Status(StepGeneratePDF, ProgressDetails{
-		File:     g.Progress.File,
-		Language: g.CurrentLanguage,
-	})
+// WritePDF renders html to a PDF file at to.
+func (g *Builder) WritePDF(html string, to string) error {
 	generator, err := wkhtmltopdf.NewPDFGenerator()
 	if err != nil {
 		return fmt.Errorf("could not initialize pdf generator: %w", err)
@@ -26,8 +23,8 @@ func WritePDF(html string, to string) error {
 		return fmt.Errorf("could not create the pdf file: %w", err)
 	}
 
-	LogDebug("writing pdf to %s", to)
-	err = generator.WriteFile(to)
+	g.LogDebug("writing pdf to %s", to)
+	err = writeFileAtomically(to, generator.Bytes(), 0o644)
 	if err != nil {
 		return fmt.Errorf("could not write the created pdf file to %q: %w", to, err)
 	}
@@ -2,8 +2,11 @@ package ortfomk
 
 import (
 	"encoding/json"
-	"io/ioutil"
+	"io"
 	"math"
+	"os"
+	"sync"
+	"time"
 )
 
 var spinner Spinner = DummySpinner{}
@@ -46,7 +49,176 @@ type ProgressFile struct {
 	} `json:"current"`
 }
 
+// Kinds of progress events, see ProgressEvent.
+const (
+	ProgressEventStepStarted   = "step started"
+	ProgressEventPageBuilt     = "page built"
+	ProgressEventError         = "error"
+	ProgressEventBuildFinished = "build finished"
+)
+
+// ProgressEvent is a line of the --progress-events file, which is a stream of newline-delimited JSON objects.
+// Each event has the shape of the --write-progress file, plus the kind of event, its timestamp and event-specific fields.
+type ProgressEvent struct {
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+	ProgressFile
+	// Time taken to build the page, in milliseconds, for "page built" events
+	Duration int64 `json:"duration,omitempty"`
+	// Whether the page was up to date and not re-built, for "page built" events
+	Skipped bool `json:"skipped,omitempty"`
+	// Error message, for "error" events
+	Message string `json:"message,omitempty"`
+	// Totals of the build, for "build finished" events
+	Totals *BuildTotals `json:"totals,omitempty"`
+}
+
+// BuildTotals sums up a finished build.
+type BuildTotals struct {
+	Built    int `json:"built"`
+	Skipped  int `json:"skipped"`
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+	// Time taken by the build, in milliseconds
+	Duration int64 `json:"duration"`
+	// Interrupted is true when the build was cancelled before every page was built
+	Interrupted bool `json:"interrupted,omitempty"`
+	// Number of pages that were not built because the build was interrupted
+	NotBuilt int `json:"notBuilt,omitempty"`
+	// Exit code of ortfomk for the build (see ExitCode).
+	// While developing, ortfomk keeps running after builds: this is the exit code it would have exited with.
+	ExitCode int `json:"exitCode"`
+}
+
+// lastBuild is what BuildFinished needs to know about the last build.
+type lastBuild struct {
+	mu           sync.Mutex
+	startedAt    time.Time
+	built        int
+	skipped      int
+	interruption *BuildInterruptedError
+}
+
+// recordLastBuild remembers the build that started at startedAt and built the given pages, for BuildFinished.
+// interruption is nil unless the build was interrupted.
+// It returns the build's totals so far.
+func (g *Builder) recordLastBuild(built []string, startedAt time.Time, interruption *BuildInterruptedError) BuildTotals {
+	g.lastBuild.mu.Lock()
+	g.lastBuild.startedAt = startedAt
+	g.lastBuild.built, g.lastBuild.skipped = len(built), 0
+	if g.Manifest != nil {
+		g.lastBuild.built, g.lastBuild.skipped = g.Manifest.Counts()
+	}
+	g.lastBuild.interruption = interruption
+	g.lastBuild.mu.Unlock()
+	return g.lastBuildTotals()
+}
+
+// lastBuildTotals sums up the last build. Errors and warnings are counted from the current build report,
+// and the duration runs until now.
+func (g *Builder) lastBuildTotals() BuildTotals {
+	g.lastBuild.mu.Lock()
+	totals := BuildTotals{
+		Built:    g.lastBuild.built,
+		Skipped:  g.lastBuild.skipped,
+		Duration: time.Since(g.lastBuild.startedAt).Milliseconds(),
+	}
+	if g.lastBuild.interruption != nil {
+		totals.Interrupted, totals.NotBuilt = true, len(g.lastBuild.interruption.NotBuilt)
+	}
+	g.lastBuild.mu.Unlock()
+	report := g.CurrentBuildReport()
+	totals.Errors, totals.Warnings = len(report.Errors), len(report.Warnings)
+	return totals
+}
+
+// BuildFinished emits the "build finished" event of the last build (see BuildAll), which exits with exitCode.
+// It is called once everything that can report errors about the build is done, such as checking for dead links,
+// so that the event's totals are complete.
+func (g *Builder) BuildFinished(exitCode int) {
+	totals := g.lastBuildTotals()
+	totals.ExitCode = exitCode
+	g.progressBuildFinishedEvent(totals)
+}
+
+// progressEvents is where the progress events of a Builder are appended to.
+type progressEvents struct {
+	mu     sync.Mutex
+	output io.WriteCloser
+}
+
+// OpenProgressEvents opens the file progress events are appended to. "-" means the standard output.
+func (g *Builder) OpenProgressEvents(path string) error {
+	g.progressEvents.mu.Lock()
+	defer g.progressEvents.mu.Unlock()
+	if path == "-" {
+		g.progressEvents.output = os.Stdout
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	g.progressEvents.output = file
+	return nil
+}
+
+// CloseProgressEvents closes the progress events file, if any.
+func (g *Builder) CloseProgressEvents() {
+	g.progressEvents.mu.Lock()
+	defer g.progressEvents.mu.Unlock()
+	if g.progressEvents.output != nil && g.progressEvents.output != os.Stdout {
+		g.progressEvents.output.Close()
+	}
+	g.progressEvents.output = nil
+}
+
+// emitProgressEvent appends event to the progress events file, if --progress-events is set.
+// It does not lock g.mu, as it's called by Status and log functions while g.mu is held.
+func (g *Builder) emitProgressEvent(event ProgressEvent) {
+	g.progressEvents.mu.Lock()
+	defer g.progressEvents.mu.Unlock()
+	if g.progressEvents.output == nil {
+		return
+	}
+	event.Timestamp = time.Now()
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	g.progressEvents.output.Write(append(encoded, '\n'))
+}
+
+// progressErrorEvent emits an "error" event.
+func (g *Builder) progressErrorEvent(message string) {
+	event := ProgressEvent{Event: ProgressEventError, Message: message}
+	if g != nil {
+		event.ProgressFile = g.ProgressFileData()
+	}
+	g.emitProgressEvent(event)
+}
+
+// progressPageErrorEvent emits an "error" event about the given page.
+func (g *Builder) progressPageErrorEvent(page ProgressDetails, message string) {
+	g.emitProgressEvent(ProgressEvent{Event: ProgressEventError, ProgressFile: g.progressFileDataAbout(StepBuildPage, page), Message: message})
+}
+
+// progressPageBuiltEvent emits a "page built" event for the given page.
+func (g *Builder) progressPageBuiltEvent(page ProgressDetails, duration time.Duration, skipped bool) {
+	g.emitProgressEvent(ProgressEvent{Event: ProgressEventPageBuilt, ProgressFile: g.progressFileDataAbout(StepBuildPage, page), Duration: duration.Milliseconds(), Skipped: skipped})
+}
+
+// progressBuildFinishedEvent emits a "build finished" event.
+func (g *Builder) progressBuildFinishedEvent(totals BuildTotals) {
+	g.emitProgressEvent(ProgressEvent{Event: ProgressEventBuildFinished, ProgressFile: g.ProgressFileData(), Totals: &totals})
+}
+
+// ProgressDetails describes what a step is about.
+// Pages are built in parallel, so goroutines building a page pass its details down (see BuildPage),
+// instead of relying on the builder's current progress, which only reflects the step started last.
 type ProgressDetails struct {
+	// ID of the object (work, tag, etc.) being built
+	ObjectID   string
 	Resolution int
 	File       string
 	Language   string
@@ -54,50 +226,67 @@ type ProgressDetails struct {
 }
 
 // Status updates the current progress and writes the progress to a file if --write-progress is set.
-func Status(step BuildStep, details ProgressDetails) {
+func (g *Builder) Status(step BuildStep, details ProgressDetails) {
 	g.mu.Lock()
-	defer g.mu.Unlock()
 	g.Progress.Step = step
 	g.Progress.Resolution = details.Resolution
 	g.Progress.File = details.File
+	g.CurrentObjectID = details.ObjectID
 	if details.Language != "" {
 		g.CurrentLanguage = details.Language
 	}
 	g.CurrentOutputFile = details.OutFile
+	progress := g.progressFileData()
+	g.mu.Unlock()
 
-	UpdateSpinner()
-	err := WriteProgressFile()
+	g.updateSpinner(progress)
+	g.emitProgressEvent(ProgressEvent{Event: ProgressEventStepStarted, ProgressFile: progress})
+	err := g.writeProgressFile(progress)
 	if err != nil {
-		LogError("Couldn't write to progress file: %s", err)
+		g.LogError("Couldn't write to progress file: %s", err)
 	}
 }
 
 // IncrementProgress increments the number of processed works and writes the progress to a file if --write-progress is set.
-func IncrementProgress() error {
+func (g *Builder) IncrementProgress() error {
 	g.mu.Lock()
-	defer g.mu.Unlock()
 	g.Progress.Current++
+	progress := g.progressFileData()
+	g.mu.Unlock()
 
-	UpdateSpinner()
-	return WriteProgressFile()
+	g.updateSpinner(progress)
+	return g.writeProgressFile(progress)
 }
 
 // WriteProgressFile writes the progress to a file if --write-progress is set.
-func WriteProgressFile() error {
+func (g *Builder) WriteProgressFile() error {
+	return g.writeProgressFile(g.ProgressFileData())
+}
+
+// writeProgressFile writes the given progress to a file if --write-progress is set.
+// The file is replaced atomically, as goroutines building pages write it concurrently.
+func (g *Builder) writeProgressFile(progress ProgressFile) error {
 	if g.Flags.ProgressFile == "" {
 		return nil
 	}
 
-	progressDataJSON, err := json.Marshal(g.ProgressFileData())
+	progressDataJSON, err := json.Marshal(progress)
 	if err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(g.Flags.ProgressFile, progressDataJSON, 0644)
+	return writeFileAtomically(g.Flags.ProgressFile, progressDataJSON, 0644)
 }
 
 // ProgressPercent returns the current progress as a percentage.
-func (g *GlobalData) ProgressPercent() int {
+func (g *Builder) ProgressPercent() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.progressPercent()
+}
+
+// progressPercent is ProgressPercent, for callers that hold g.mu.
+func (g *Builder) progressPercent() int {
 	if g.Progress.Total == 0 {
 		return 0
 	}
@@ -105,11 +294,30 @@ func (g *GlobalData) ProgressPercent() int {
 }
 
 // ProgressFileData returns a ProgressData struct ready to be marshalled to JSON for --write-progress.
-func (g *GlobalData) ProgressFileData() ProgressFile {
+func (g *Builder) ProgressFileData() ProgressFile {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.progressFileData()
+}
+
+// progressFileDataAbout returns the current progress, with page, at the given step, as what is being done.
+func (g *Builder) progressFileDataAbout(step BuildStep, page ProgressDetails) ProgressFile {
+	progress := g.ProgressFileData()
+	progress.Current.ID = page.ObjectID
+	progress.Current.Step = step
+	progress.Current.Resolution = page.Resolution
+	progress.Current.File = page.File
+	progress.Current.Language = page.Language
+	progress.Current.Output = page.OutFile
+	return progress
+}
+
+// progressFileData is ProgressFileData, for callers that hold g.mu.
+func (g *Builder) progressFileData() ProgressFile {
 	return ProgressFile{
 		Total:     g.Progress.Total,
 		Processed: g.Progress.Current,
-		Percent:   g.ProgressPercent(),
+		Percent:   g.progressPercent(),
 		Current: struct {
 			ID         string    `json:"id"`
 			Step       BuildStep `json:"step"`
@@ -129,10 +337,11 @@ func (g *GlobalData) ProgressFileData() ProgressFile {
 }
 
 // SetCurrentObjectID sets the current object ID and updates the spinner.
-func SetCurrentObjectID(objectID string) {
+// Status sets it too, from the ProgressDetails it is given.
+func (g *Builder) SetCurrentObjectID(objectID string) {
 	g.mu.Lock()
 	g.CurrentObjectID = objectID
 	g.mu.Unlock()
 	// UpdateSpinner is thread-safe, as yacspin has its own mutex.
-	UpdateSpinner()
+	g.UpdateSpinner()
 }
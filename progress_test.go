@@ -0,0 +1,83 @@
+package ortfomk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressEvents(t *testing.T) {
+	defer SetGlobalData(g)
+	SetGlobalData(&GlobalData{})
+	g.Progress.Total = 4
+	g.Progress.Current = 1
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"event":"build finished"}`+"\n"), 0o644))
+
+	assert.NoError(t, OpenProgressEvents(path))
+	page := ProgressDetails{ObjectID: "poster", File: "src/:work.pug", Language: "fr", OutFile: "dist/fr/poster.html"}
+	g.progressPageBuiltEvent(page, 1500*time.Millisecond, false)
+	g.progressErrorEvent("couldn't execute template")
+	g.progressBuildFinishedEvent(BuildTotals{Built: 3, Skipped: 1, Errors: 1})
+	CloseProgressEvents()
+	// Events emitted once closed are dropped
+	g.progressErrorEvent("dropped")
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+	events := make([]ProgressEvent, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event ProgressEvent
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+
+	assert.Len(t, events, 4, "events are appended to the existing file")
+	assert.Equal(t, ProgressEventPageBuilt, events[1].Event)
+	assert.Equal(t, int64(1500), events[1].Duration)
+	assert.Equal(t, "poster", events[1].Current.ID)
+	assert.Equal(t, "fr", events[1].Current.Language)
+	assert.Equal(t, "dist/fr/poster.html", events[1].Current.Output)
+	assert.Equal(t, 25, events[1].Percent)
+	assert.False(t, events[1].Timestamp.IsZero())
+	assert.Equal(t, ProgressEventError, events[2].Event)
+	assert.Equal(t, "couldn't execute template", events[2].Message)
+	assert.Equal(t, ProgressEventBuildFinished, events[3].Event)
+	assert.Equal(t, &BuildTotals{Built: 3, Skipped: 1, Errors: 1}, events[3].Totals)
+}
+
+func TestBuildFinished(t *testing.T) {
+	builder := newTestBuilder(t, "finished")
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	assert.NoError(t, builder.OpenProgressEvents(path))
+	_, _, err := builder.BuildAll(context.Background(), builder.TemplatesDirectory, 0)
+	assert.NoError(t, err)
+	// Reported after the build, e.g. while checking for dead links
+	builder.LogError("dead link")
+	builder.BuildFinished(ExitCodeErrors)
+	builder.CloseProgressEvents()
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	var finished []ProgressEvent
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var event ProgressEvent
+		assert.NoError(t, json.Unmarshal([]byte(line), &event))
+		if event.Event == ProgressEventBuildFinished {
+			finished = append(finished, event)
+		}
+	}
+	assert.Len(t, finished, 1)
+	assert.Equal(t, 1, finished[0].Totals.Built)
+	assert.Equal(t, 1, finished[0].Totals.Errors)
+	assert.Equal(t, ExitCodeErrors, finished[0].Totals.ExitCode)
+}
@@ -0,0 +1,12 @@
+//go:build embedpug
+
+package ortfomk
+
+import (
+	_ "embed"
+)
+
+// pugCompilerBundle is pug's compiler, bundled into a single script by go generate (see the pug-bundle target of the Makefile).
+//
+//go:embed pug.bundle.js
+var pugCompilerBundle string
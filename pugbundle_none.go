@@ -0,0 +1,7 @@
+//go:build !embedpug
+
+package ortfomk
+
+// pugCompilerBundle is empty when ortfomk is built without the embedpug build tag.
+// The embedded pug compiler is then unavailable, and the pug CLI is used by default instead (see defaultPugCompiler).
+var pugCompilerBundle string
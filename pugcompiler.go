@@ -0,0 +1,193 @@
+package ortfomk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	v8 "rogchap.com/v8go"
+)
+
+//go:generate make pug-bundle
+
+// Values for the "pug compiler" configuration key
+const (
+	// PugCompilerCLI compiles templates by running the pug CLI tool, which needs to be installed (npm install -g pug-cli)
+	PugCompilerCLI = "cli"
+	// PugCompilerEmbedded compiles templates with the pug compiler bundled into ortfomk, running it in V8.
+	// ortfomk needs to be built with the embedpug build tag, after generating the bundle with go generate (see the Makefile, which does both).
+	PugCompilerEmbedded = "embedded"
+)
+
+// defaultPugCompiler is the embedded pug compiler, or the pug CLI when ortfomk was built without the embedpug build tag.
+func defaultPugCompiler() string {
+	if pugCompilerBundle == "" {
+		return PugCompilerCLI
+	}
+	return PugCompilerEmbedded
+}
+
+// compileTemplate compiles a pug template into a client-side template function named "template",
+// using the pug compiler selected in the configuration.
+func (g *Builder) compileTemplate(templateName string, templateContent []byte) ([]byte, error) {
+	pugCompiler := g.Configuration.PugCompiler
+	if pugCompiler == "" {
+		pugCompiler = defaultPugCompiler()
+	}
+	switch pugCompiler {
+	case PugCompilerEmbedded:
+		compiler, err := g.sharedEmbeddedPugCompiler()
+		if err != nil {
+			return []byte{}, fmt.Errorf("while starting the embedded pug compiler: %w", err)
+		}
+		return compiler.Compile(templateName, templateContent)
+	case PugCompilerCLI:
+		return compileTemplateWithCLI(templateName, templateContent)
+	default:
+		return []byte{}, fmt.Errorf("unknown pug compiler %q, use %q or %q", g.Configuration.PugCompiler, PugCompilerCLI, PugCompilerEmbedded)
+	}
+}
+
+// compileTemplateWithCLI compiles a pug template using the CLI tool pug.
+func compileTemplateWithCLI(templateName string, templateContent []byte) ([]byte, error) {
+	command := exec.Command("pug", "--client", "--path", templateName)
+	LogDebug("compiling template: running %s", command)
+	command.Stdin = bytes.NewReader(templateContent)
+	command.Stderr = os.Stderr
+
+	return command.Output()
+}
+
+// pugCompilerEnvironment stubs out the Node.js globals that the bundled pug compiler expects to exist.
+// Reading files and resolving includes is done by Go callbacks instead (see newEmbeddedPugCompiler).
+const pugCompilerEnvironment = `
+var process = { env: {}, platform: "linux", cwd: () => "/" }
+var require = name => {
+	if (name === "fs") return {}
+	throw new Error("the pug compiler tried to require " + name + ", which is not available")
+}
+`
+
+// pugCompileCall compiles __ortfomk_source, using the Go callbacks to resolve and read included files.
+const pugCompileCall = `
+pug.compileClient(__ortfomk_source, {
+	name: "template",
+	filename: __ortfomk_filename,
+	basedir: __ortfomk_basedir,
+	compileDebug: true,
+	plugins: [{
+		resolve: (filename, source) => __ortfomk_resolve(filename, source),
+		read: filename => __ortfomk_read(filename),
+	}],
+})
+`
+
+// embeddedPugCompiler runs a bundled pug compiler in its own V8 isolate.
+// The compiler script is evaluated once, and V8 isolates can't be used by multiple goroutines at once,
+// so compilations are serialized.
+type embeddedPugCompiler struct {
+	mu      sync.Mutex
+	context *v8.Context
+}
+
+// embeddedPugCompilerInstance is the embedded pug compiler of a Builder, started on first use.
+// Each Builder has its own, since includes are resolved relative to its templates directory.
+type embeddedPugCompilerInstance struct {
+	once     sync.Once
+	compiler *embeddedPugCompiler
+	err      error
+}
+
+// sharedEmbeddedPugCompiler returns the embedded pug compiler, starting it on first use.
+func (g *Builder) sharedEmbeddedPugCompiler() (*embeddedPugCompiler, error) {
+	g.embeddedPugCompilerInstance.once.Do(func() {
+		if pugCompilerBundle == "" {
+			g.embeddedPugCompilerInstance.err = errors.New("this build of ortfomk does not include the pug compiler: run go generate, then build with -tags embedpug; or set pug compiler to \"cli\" in the configuration")
+			return
+		}
+		g.embeddedPugCompilerInstance.compiler, g.embeddedPugCompilerInstance.err = newEmbeddedPugCompiler(pugCompilerBundle, g.TemplatesDirectory)
+	})
+	return g.embeddedPugCompilerInstance.compiler, g.embeddedPugCompilerInstance.err
+}
+
+// newEmbeddedPugCompiler evaluates bundle, which must declare a global pug object exposing compileClient.
+// Absolute include paths are resolved relative to basedir.
+func newEmbeddedPugCompiler(bundle string, basedir string) (*embeddedPugCompiler, error) {
+	isolate := v8.NewIsolate()
+	throw := func(message string) *v8.Value {
+		value, _ := v8.NewValue(isolate, message)
+		return isolate.ThrowException(value)
+	}
+
+	global := v8.NewObjectTemplate(isolate)
+	global.Set("__ortfomk_basedir", basedir)
+	global.Set("__ortfomk_resolve", v8.NewFunctionTemplate(isolate, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		args := info.Args()
+		if len(args) < 2 {
+			return throw("resolve needs a filename and the file that includes it")
+		}
+		resolved, err := resolvePugInclude(args[0].String(), args[1].String(), basedir)
+		if err != nil {
+			return throw(err.Error())
+		}
+		value, _ := v8.NewValue(isolate, resolved)
+		return value
+	}))
+	global.Set("__ortfomk_read", v8.NewFunctionTemplate(isolate, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		args := info.Args()
+		if len(args) < 1 {
+			return throw("read needs a filename")
+		}
+		content, err := os.ReadFile(args[0].String())
+		if err != nil {
+			return throw(fmt.Sprintf("while reading %s: %s", args[0].String(), err))
+		}
+		value, _ := v8.NewValue(isolate, string(content))
+		return value
+	}))
+
+	context := v8.NewContext(isolate, global)
+	if _, err := context.RunScript(pugCompilerEnvironment, "pug-environment.js"); err != nil {
+		return nil, fmt.Errorf("while setting up the pug compiler's environment: %w", err)
+	}
+	if _, err := context.RunScript(bundle, "pug.bundle.js"); err != nil {
+		return nil, fmt.Errorf("while loading the pug compiler: %w", err)
+	}
+	return &embeddedPugCompiler{context: context}, nil
+}
+
+// Compile compiles the given pug template, as `pug --client --path templateName` would.
+func (c *embeddedPugCompiler) Compile(templateName string, templateContent []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	LogDebug("compiling template %s with the embedded pug compiler", templateName)
+	if err := c.context.Global().Set("__ortfomk_source", string(templateContent)); err != nil {
+		return []byte{}, err
+	}
+	if err := c.context.Global().Set("__ortfomk_filename", templateName); err != nil {
+		return []byte{}, err
+	}
+	compiled, err := c.context.RunScript(pugCompileCall, "compile.js")
+	if err != nil {
+		return []byte{}, fmt.Errorf("while compiling %s: %w", templateName, err)
+	}
+	return []byte(compiled.String()), nil
+}
+
+// resolvePugInclude resolves the path of an included or extended file the same way pug does:
+// absolute paths are relative to basedir, and relative ones are relative to the including file.
+func resolvePugInclude(filename string, includedFrom string, basedir string) (string, error) {
+	filename = strings.TrimSpace(filename)
+	if strings.HasPrefix(filename, "/") {
+		return filepath.Join(basedir, filename), nil
+	}
+	if strings.TrimSpace(includedFrom) == "" {
+		return "", fmt.Errorf("can't resolve relative path %q without knowing which file includes it", filename)
+	}
+	return filepath.Join(filepath.Dir(strings.TrimSpace(includedFrom)), filename), nil
+}
@@ -0,0 +1,57 @@
+package ortfomk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePugBundle stands in for pug's compiler: it "compiles" a template by including a mixin file verbatim.
+const fakePugBundle = `
+var pug = {
+	compileClient: (source, options) => {
+		const plugin = options.plugins[0]
+		const mixin = plugin.read(plugin.resolve("mixins/card.pug", options.filename))
+		return "function " + options.name + "(locals) { return " + JSON.stringify(source + mixin) + " }"
+	},
+}
+`
+
+func TestEmbeddedPugCompiler(t *testing.T) {
+	templates := t.TempDir()
+	os.MkdirAll(filepath.Join(templates, "mixins"), 0755)
+	os.WriteFile(filepath.Join(templates, "mixins", "card.pug"), []byte("+card"), 0644)
+
+	compiler, err := newEmbeddedPugCompiler(fakePugBundle, templates)
+	assert.NoError(t, err)
+
+	compiled, err := compiler.Compile(filepath.Join(templates, "index.pug"), []byte("p hi "))
+	assert.NoError(t, err)
+	assert.Equal(t, `function template(locals) { return "p hi +card" }`, string(compiled))
+
+	_, err = compiler.Compile(filepath.Join(templates, "nested", "index.pug"), []byte("p hi "))
+	assert.ErrorContains(t, err, "while reading")
+}
+
+func TestResolvePugInclude(t *testing.T) {
+	resolved, err := resolvePugInclude("/mixins/card.pug", "/site/src/works/:work.pug", "/site/src")
+	assert.NoError(t, err)
+	assert.Equal(t, "/site/src/mixins/card.pug", resolved)
+
+	resolved, err = resolvePugInclude(" ../layout.pug", "/site/src/works/:work.pug", "/site/src")
+	assert.NoError(t, err)
+	assert.Equal(t, "/site/src/layout.pug", resolved)
+
+	_, err = resolvePugInclude("layout.pug", "", "/site/src")
+	assert.Error(t, err)
+}
+
+func TestDefaultPugCompiler(t *testing.T) {
+	if pugCompilerBundle == "" {
+		assert.Equal(t, PugCompilerCLI, DefaultConfiguration().PugCompiler)
+	} else {
+		assert.Equal(t, PugCompilerEmbedded, DefaultConfiguration().PugCompiler)
+	}
+}
@@ -0,0 +1,148 @@
+package ortfomk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// What can make a build fail, see --fail-on. Errors always do.
+const (
+	FailOnError              = "error"
+	FailOnWarning            = "warning"
+	FailOnDeadLink           = "deadlink"
+	FailOnMissingTranslation = "missing-translation"
+)
+
+// Exit codes of the build command, one per reason to fail (see BuildReport.ExitCode).
+// When the build fails for multiple reasons, errors take precedence over dead links,
+// dead links over missing translations, and missing translations over warnings.
+const (
+	ExitCodeSuccess             = 0
+	ExitCodeErrors              = 1
+	ExitCodeWarnings            = 2
+	ExitCodeDeadLinks           = 3
+	ExitCodeMissingTranslations = 4
+)
+
+// BuildReport collects what went wrong during a build.
+type BuildReport struct {
+	Errors   []string
+	Warnings []string
+	// Maps dead links to the pages they appear in
+	DeadLinks map[string][]string
+	// Maps languages to the number of messages missing from their translation catalog
+	MissingTranslations map[string]int
+}
+
+// buildReport collects what went wrong during the builds of a Builder.
+type buildReport struct {
+	mu sync.Mutex
+	BuildReport
+}
+
+// resetBuildReport forgets what went wrong during previous builds, before starting a new full build.
+func (g *Builder) resetBuildReport() {
+	g.buildReport.mu.Lock()
+	defer g.buildReport.mu.Unlock()
+	g.buildReport.BuildReport = BuildReport{}
+}
+
+func (g *Builder) recordError(message string) {
+	g.buildReport.mu.Lock()
+	defer g.buildReport.mu.Unlock()
+	g.buildReport.Errors = append(g.buildReport.Errors, message)
+}
+
+func (g *Builder) recordWarning(message string) {
+	g.buildReport.mu.Lock()
+	defer g.buildReport.mu.Unlock()
+	g.buildReport.Warnings = append(g.buildReport.Warnings, message)
+}
+
+// RecordDeadLink adds a dead link, found in the given pages, to the build report.
+func (g *Builder) RecordDeadLink(link string, pages []string) {
+	g.buildReport.mu.Lock()
+	defer g.buildReport.mu.Unlock()
+	if g.buildReport.DeadLinks == nil {
+		g.buildReport.DeadLinks = make(map[string][]string)
+	}
+	g.buildReport.DeadLinks[link] = pages
+}
+
+// CurrentBuildReport returns what went wrong so far. Missing translations are counted from the loaded translation catalogs.
+func (g *Builder) CurrentBuildReport() BuildReport {
+	g.buildReport.mu.Lock()
+	defer g.buildReport.mu.Unlock()
+	report := BuildReport{
+		Errors:              append([]string{}, g.buildReport.Errors...),
+		Warnings:            append([]string{}, g.buildReport.Warnings...),
+		DeadLinks:           make(map[string][]string),
+		MissingTranslations: make(map[string]int),
+	}
+	for link, pages := range g.buildReport.DeadLinks {
+		report.DeadLinks[link] = pages
+	}
+	for language, translations := range g.Translations {
+		if missing := len(translations.MissingMessages()); missing > 0 {
+			report.MissingTranslations[language] = missing
+		}
+	}
+	return report
+}
+
+// ParseFailOn parses the value of --fail-on: a comma-separated list of FailOn* values.
+func ParseFailOn(value string) (failOn []string, err error) {
+	for _, reason := range strings.Split(value, ",") {
+		reason = strings.TrimSpace(reason)
+		if reason == "" {
+			continue
+		}
+		if !contains([]string{FailOnError, FailOnWarning, FailOnDeadLink, FailOnMissingTranslation}, reason) {
+			return failOn, fmt.Errorf("unknown --fail-on value %q, use %q, %q, %q or %q", reason, FailOnError, FailOnWarning, FailOnDeadLink, FailOnMissingTranslation)
+		}
+		failOn = append(failOn, reason)
+	}
+	return
+}
+
+// ExitCode returns the exit code for the build: ExitCodeErrors if errors were logged,
+// ExitCodeSuccess unless something else listed in failOn happened otherwise.
+// Errors always make the build fail, FailOnError being listed in failOn or not.
+func (r BuildReport) ExitCode(failOn []string) int {
+	missingTranslations := 0
+	for _, count := range r.MissingTranslations {
+		missingTranslations += count
+	}
+	switch {
+	case len(r.Errors) > 0:
+		return ExitCodeErrors
+	case contains(failOn, FailOnDeadLink) && len(r.DeadLinks) > 0:
+		return ExitCodeDeadLinks
+	case contains(failOn, FailOnMissingTranslation) && missingTranslations > 0:
+		return ExitCodeMissingTranslations
+	case contains(failOn, FailOnWarning) && len(r.Warnings) > 0:
+		return ExitCodeWarnings
+	}
+	return ExitCodeSuccess
+}
+
+// Summary describes the report in a few lines.
+func (r BuildReport) Summary() string {
+	lines := []string{fmt.Sprintf("%d errors, %d warnings, %d dead links", len(r.Errors), len(r.Warnings), len(r.DeadLinks))}
+	languages := keys(r.MissingTranslations)
+	sort.Strings(languages)
+	for _, language := range languages {
+		lines = append(lines, fmt.Sprintf("%d messages missing from the %s translation", r.MissingTranslations[language], language))
+	}
+	for _, message := range r.Errors {
+		lines = append(lines, "error: "+message)
+	}
+	links := keys(r.DeadLinks)
+	sort.Strings(links)
+	for _, link := range links {
+		lines = append(lines, fmt.Sprintf("dead link: %s (from %s)", link, strings.Join(r.DeadLinks[link], ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,37 @@
+package ortfomk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFailOn(t *testing.T) {
+	failOn, err := ParseFailOn("error, deadlink")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{FailOnError, FailOnDeadLink}, failOn)
+
+	_, err = ParseFailOn("errors")
+	assert.Error(t, err)
+}
+
+func TestBuildReportExitCode(t *testing.T) {
+	report := BuildReport{
+		Warnings:            []string{"no ortfomk.yaml found"},
+		DeadLinks:           map[string][]string{"https://example.com": {"dist/en/index.html"}},
+		MissingTranslations: map[string]int{"fr": 2},
+	}
+	assert.Equal(t, ExitCodeSuccess, report.ExitCode([]string{FailOnError}))
+	assert.Equal(t, ExitCodeWarnings, report.ExitCode([]string{FailOnError, FailOnWarning}))
+	assert.Equal(t, ExitCodeMissingTranslations, report.ExitCode([]string{FailOnWarning, FailOnMissingTranslation}))
+	assert.Equal(t, ExitCodeDeadLinks, report.ExitCode([]string{FailOnWarning, FailOnMissingTranslation, FailOnDeadLink}))
+
+	report.Errors = []string{"couldn't execute template"}
+	assert.Equal(t, ExitCodeErrors, report.ExitCode([]string{FailOnError, FailOnDeadLink}))
+	assert.Equal(t, ExitCodeErrors, report.ExitCode([]string{FailOnWarning}), "errors always fail the build")
+	assert.Equal(t, ExitCodeErrors, report.ExitCode(nil))
+	assert.Equal(t, `1 errors, 1 warnings, 1 dead links
+2 messages missing from the fr translation
+error: couldn't execute template
+dead link: https://example.com (from dist/en/index.html)`, report.Summary())
+}
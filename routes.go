@@ -0,0 +1,153 @@
+package ortfomk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Route is a page that a build produces: a template rendered with a hydration to an output file.
+type Route struct {
+	// Template path, relative to the templates directory
+	Template string `json:"template"`
+	// Name of the hydration (see Hydration.Name)
+	Hydration string `json:"hydration"`
+	OutPath   string `json:"output"`
+	// Collision is true when other routes are built to the same output file.
+	Collision bool `json:"collision"`
+}
+
+// RoutesError lists why some routes could not be computed by Routes, which still returns the other routes.
+type RoutesError struct {
+	Errors []error
+}
+
+func (e *RoutesError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d routes could not be computed: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// HydrationsOf returns every hydration the template at path is rendered with, in every language.
+// This is the same logic BuildAll uses to choose which Build*Pages function to call.
+func (g *Builder) HydrationsOf(path string) (hydrations []*Hydration, err error) {
+	// Collect variables the path depends upon
+	pathVariables := make([]string, 0)
+	for _, expr := range DynamicPathExpressions(path) {
+		variables, err := VariablesOfExpression(expr)
+		if err != nil {
+			return hydrations, fmt.Errorf("couldn't extract variables of expression %q: %w", expr, err)
+		}
+		pathVariables = append(pathVariables, variables...)
+	}
+	pathVariables = deduplicate(pathVariables)
+
+	for _, language := range g.Configuration.Languages {
+		if len(excluding(pathVariables, "language")) == 0 {
+			hydrations = append(hydrations, &Hydration{language: language})
+			continue
+		}
+		for _, variable := range pathVariables {
+			switch variable {
+			case "work":
+				for _, work := range g.Works {
+					hydrations = append(hydrations, &Hydration{language: language, work: work})
+				}
+			case "tag":
+				for _, tag := range g.Tags {
+					hydrations = append(hydrations, &Hydration{language: language, tag: tag})
+				}
+			case "technology":
+				for _, tech := range g.Technologies {
+					hydrations = append(hydrations, &Hydration{language: language, tech: tech})
+				}
+			case "site":
+				for _, site := range g.Sites {
+					hydrations = append(hydrations, &Hydration{language: language, site: site})
+				}
+			case "collection":
+				for _, collection := range g.Collections {
+					hydrations = append(hydrations, &Hydration{language: language, collection: collection})
+				}
+			}
+		}
+	}
+	return
+}
+
+// Routes returns every page that building the templates in the given directory produces, sorted by output path.
+// Routes whose output file is also the output file of another page are marked as collisions.
+// A template whose path doesn't depend on the language has a route per language to the same output file, which is not a collision.
+// Routes that can't be computed are skipped, and listed in the returned *RoutesError.
+func (g *Builder) Routes(in string) (routes []Route, err error) {
+	// Maps output files to the pages built to them, as template and object ID, regardless of the language
+	pagesOf := make(map[string]map[string]bool)
+	templates, err := g.ScanAll(in)
+	if err != nil {
+		return routes, fmt.Errorf("while scanning templates directory: %w", err)
+	}
+	failures := make([]error, 0)
+	for _, template := range templates {
+		hydrations, err := g.HydrationsOf(template)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("while listing hydrations of %s: %w", template, err))
+			continue
+		}
+		for _, hydration := range hydrations {
+			outPath, err := g.GetDistFilepath(hydration, template)
+			if err != nil {
+				failures = append(failures, fmt.Errorf("while computing output path of %s with %s: %w", template, hydration.Name(), err))
+				continue
+			}
+			if outPath == "" {
+				continue
+			}
+			if pagesOf[outPath] == nil {
+				pagesOf[outPath] = make(map[string]bool)
+			}
+			pagesOf[outPath][template+"\x00"+hydration.ObjectID()] = true
+			routes = append(routes, Route{
+				Template:  g.GetPathRelativeToSrcDir(template),
+				Hydration: hydration.Name(),
+				OutPath:   outPath,
+			})
+		}
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool { return routes[i].OutPath < routes[j].OutPath })
+	for i := range routes {
+		routes[i].Collision = len(pagesOf[routes[i].OutPath]) > 1
+	}
+	if len(failures) > 0 {
+		err = &RoutesError{Errors: failures}
+	}
+	return
+}
+
+// WriteRoutes writes routes to w, as a table or as JSON.
+func WriteRoutes(w io.Writer, routes []Route, asJSON bool) error {
+	if asJSON {
+		if routes == nil {
+			routes = []Route{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(routes)
+	}
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "TEMPLATE\tHYDRATION\tOUTPUT")
+	for _, route := range routes {
+		if route.Collision {
+			fmt.Fprintf(table, "%s\t%s\t%s\tcollision\n", route.Template, route.Hydration, route.OutPath)
+		} else {
+			fmt.Fprintf(table, "%s\t%s\t%s\n", route.Template, route.Hydration, route.OutPath)
+		}
+	}
+	return table.Flush()
+}
@@ -0,0 +1,58 @@
+package ortfomk
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutes(t *testing.T) {
+	defer SetGlobalData(g)
+	templates := t.TempDir()
+	config := DefaultConfiguration()
+	config.Languages = []string{"en"}
+	SetGlobalData(&GlobalData{Configuration: config, TemplatesDirectory: templates})
+	work := func(id string) Work {
+		w := Work{}
+		w.ID = id
+		return w
+	}
+	SetDatabaseOnGlobalData(Database{Works: []Work{work("about"), work("poster")}})
+	os.WriteFile(filepath.Join(templates, ":work.pug"), []byte("p work"), 0644)
+	os.WriteFile(filepath.Join(templates, "about.pug"), []byte("p about"), 0644)
+
+	routes, err := Routes(templates)
+	assert.NoError(t, err)
+	assert.Equal(t, []Route{
+		{Template: ":work.pug", Hydration: "about@en", OutPath: filepath.Join("dist", "about.html"), Collision: true},
+		{Template: "about.pug", Hydration: "en", OutPath: filepath.Join("dist", "about.html"), Collision: true},
+		{Template: ":work.pug", Hydration: "poster@en", OutPath: filepath.Join("dist", "poster.html")},
+	}, routes)
+
+	table := bytes.Buffer{}
+	assert.NoError(t, WriteRoutes(&table, routes, false))
+	assert.Equal(t, `TEMPLATE   HYDRATION  OUTPUT
+:work.pug  about@en   dist/about.html  collision
+about.pug  en         dist/about.html  collision
+:work.pug  poster@en  dist/poster.html
+`, table.String())
+}
+
+func TestRoutesSkipsRoutesThatCantBeComputed(t *testing.T) {
+	builder := newTestBuilder(t, "routes")
+	poster := Work{}
+	poster.ID = "poster"
+	builder.Database = Database{Works: []Work{poster}}
+	addTestTemplate(t, builder, ":[work.ID.nope].pug", "p broken", "")
+	addTestTemplate(t, builder, ":[work +].pug", "p broken", "")
+
+	routes, err := builder.Routes(builder.TemplatesDirectory)
+	var routesErr *RoutesError
+	assert.ErrorAs(t, err, &routesErr)
+	assert.Len(t, routesErr.Errors, 2)
+	assert.Equal(t, []Route{{Template: "index.pug", Hydration: "en", OutPath: filepath.Join(builder.OutputDirectory, "index.html")}}, routes)
+	assert.Equal(t, 1, builder.ToBuildTotalCount(builder.TemplatesDirectory))
+}
@@ -0,0 +1,217 @@
+package ortfomk
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SearchIndexFilename is the name of the search index file, written at the root of each language's directory.
+const SearchIndexFilename = "search-index.json"
+
+// SearchIndex is a full-text index of works in a language, queried client-side (see searchIndexClient in template.js).
+type SearchIndex struct {
+	Language  string           `json:"language"`
+	Stemmer   Stemmer          `json:"stemmer"`
+	StopWords []string         `json:"stopWords"`
+	Documents []SearchDocument `json:"documents"`
+	// Maps each stemmed term to the documents it appears in, as [document index, weight] pairs
+	Terms map[string][][2]int `json:"terms"`
+}
+
+// SearchDocument is a work, as returned in search results.
+type SearchDocument struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// URL of the work's page, relative to the search index file
+	URL     string `json:"url"`
+	Summary string `json:"summary"`
+}
+
+// Stemmer reduces words to their stem by removing suffixes.
+// It is data-driven so that the exact same stemming can be applied client-side to search queries.
+type Stemmer struct {
+	// Rules that would make the word shorter than this many characters are not applied
+	MinimumStemLength int `json:"minimumStemLength"`
+	// Each step replaces the first matching suffix with its replacement, as [suffix, replacement] pairs
+	Steps [][][2]string `json:"steps"`
+}
+
+// Weights of terms depending on where they appear in the work.
+const (
+	searchWeightTitle     = 5
+	searchWeightTaxonomy  = 3
+	searchWeightParagraph = 1
+)
+
+var doubledConsonants = [][2]string{{"bb", "b"}, {"dd", "d"}, {"gg", "g"}, {"ll", "l"}, {"mm", "m"}, {"nn", "n"}, {"pp", "p"}, {"rr", "r"}, {"tt", "t"}}
+
+// stemmers are light suffix-stripping stemmers. Words are lowercased and stripped of accents before stemming.
+var stemmers = map[string]Stemmer{
+	"en": {
+		MinimumStemLength: 3,
+		Steps: [][][2]string{
+			{{"sses", "ss"}, {"ies", "y"}, {"ied", "y"}, {"ss", "ss"}, {"us", "us"}, {"is", "is"}, {"s", ""}},
+			{{"ingly", ""}, {"edly", ""}, {"ing", ""}, {"ed", ""}, {"ly", ""}, {"ment", ""}, {"ness", ""}, {"ful", ""}},
+			append([][2]string{{"e", ""}}, doubledConsonants...),
+		},
+	},
+	"fr": {
+		MinimumStemLength: 3,
+		Steps: [][][2]string{
+			{{"eaux", "eau"}, {"aux", "al"}, {"s", ""}, {"x", ""}},
+			{{"issement", ""}, {"ement", ""}, {"ment", ""}, {"ation", ""}, {"atrice", ""}, {"ateur", ""}, {"ite", ""}, {"ique", ""}, {"isme", ""}, {"iste", ""}, {"able", ""}, {"euse", "eu"}, {"eux", "eu"}, {"ive", "if"}},
+			{{"ee", ""}, {"er", ""}, {"ez", ""}, {"e", ""}},
+			doubledConsonants,
+		},
+	},
+}
+
+var stopWords = map[string][]string{
+	"en": {"an", "and", "are", "as", "at", "be", "by", "for", "from", "in", "is", "it", "of", "on", "or", "that", "the", "this", "to", "was", "with"},
+	"fr": {"au", "aux", "ce", "cette", "dans", "de", "des", "du", "elle", "en", "est", "et", "il", "la", "le", "les", "par", "pour", "que", "qui", "sa", "se", "ses", "son", "sur", "un", "une", "avec"},
+}
+
+// Stem returns the stem of the given (lowercased, accent-free) word.
+func (s Stemmer) Stem(word string) string {
+	for _, step := range s.Steps {
+		for _, rule := range step {
+			suffix, replacement := rule[0], rule[1]
+			if strings.HasSuffix(word, suffix) && len([]rune(word))-len([]rune(suffix))+len([]rune(replacement)) >= s.MinimumStemLength {
+				word = strings.TrimSuffix(word, suffix) + replacement
+				break
+			}
+		}
+	}
+	return word
+}
+
+// foldAccents removes accents from text, by decomposing letters (NFD) and removing the resulting combining marks,
+// the same way the search script does client-side (see template.js).
+func foldAccents(text string) string {
+	var folded strings.Builder
+	for _, r := range norm.NFD.String(text) {
+		if !unicode.Is(unicode.Mn, r) {
+			folded.WriteRune(r)
+		}
+	}
+	return folded.String()
+}
+
+// SearchTerms splits text into words and returns their stems in language, leaving out stop words.
+func SearchTerms(language string, text string) (terms []string) {
+	words := strings.FieldsFunc(foldAccents(strings.ToLower(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		if len([]rune(word)) < 2 || contains(stopWords[language], word) {
+			continue
+		}
+		terms = append(terms, stemmers[language].Stem(word))
+	}
+	return
+}
+
+// SearchIndexPath returns where the search index of language is written.
+func (g *Builder) SearchIndexPath(language string) string {
+	return filepath.Join(g.OutputDirectory, strings.ReplaceAll(g.Configuration.Development.OutputTo.Translated, "<language>", language), SearchIndexFilename)
+}
+
+// BuildSearchIndex indexes public works in language, from their title, paragraphs, tags and technologies.
+func (g *Builder) BuildSearchIndex(language string) SearchIndex {
+	index := SearchIndex{
+		Language:  language,
+		Stemmer:   stemmers[language],
+		StopWords: stopWords[language],
+		Documents: make([]SearchDocument, 0),
+		Terms:     make(map[string][][2]int),
+	}
+	if index.Stemmer.Steps == nil {
+		index.Stemmer.Steps = [][][2]string{}
+	}
+	if index.StopWords == nil {
+		index.StopWords = []string{}
+	}
+
+	for _, work := range GetOneLang(language, g.PublicWorks()...) {
+		document := SearchDocument{
+			ID:      work.ID,
+			Title:   work.Title,
+			Summary: work.Summary(),
+		}
+		if outPath := g.sitemap.outPathOf(work.ID, language); outPath != "" {
+			if url, err := filepath.Rel(filepath.Dir(g.SearchIndexPath(language)), outPath); err == nil {
+				document.URL = filepath.ToSlash(url)
+			}
+		}
+
+		weights := make(map[string]int)
+		addTerms := func(text string, weight int) {
+			for _, term := range SearchTerms(language, text) {
+				weights[term] += weight
+			}
+		}
+		addTerms(work.Title, searchWeightTitle)
+		for _, paragraph := range work.Paragraphs {
+			text, err := paragraphToText(paragraph.Content)
+			if err != nil {
+				g.LogError("while indexing paragraph of %s: %s", work.ID, err)
+				continue
+			}
+			addTerms(text, searchWeightParagraph)
+		}
+		for _, name := range work.Metadata.Tags {
+			if tag, found := g.findTag(name); found {
+				addTerms(tag.Singular+" "+tag.Plural, searchWeightTaxonomy)
+			} else {
+				addTerms(name, searchWeightTaxonomy)
+			}
+		}
+		for _, name := range work.Metadata.MadeWith {
+			if technology, found := g.findTechnology(name); found {
+				addTerms(technology.DisplayName+" "+technology.Author, searchWeightTaxonomy)
+			} else {
+				addTerms(name, searchWeightTaxonomy)
+			}
+		}
+
+		documentIndex := len(index.Documents)
+		index.Documents = append(index.Documents, document)
+		terms := keys(weights)
+		sort.Strings(terms)
+		for _, term := range terms {
+			index.Terms[term] = append(index.Terms[term], [2]int{documentIndex, weights[term]})
+		}
+	}
+	return index
+}
+
+func (g *Builder) findTechnology(name string) (Technology, bool) {
+	for _, technology := range g.Technologies {
+		if technology.ReferredToBy(name) {
+			return technology, true
+		}
+	}
+	return Technology{}, false
+}
+
+// WriteSearchIndexes writes the search index of every language, and returns the paths of the files written.
+func (g *Builder) WriteSearchIndexes() (written []string, err error) {
+	for _, language := range g.Configuration.Languages {
+		path := g.SearchIndexPath(language)
+		content, err := json.Marshal(g.BuildSearchIndex(language))
+		if err != nil {
+			return written, fmt.Errorf("while encoding search index for %s: %w", language, err)
+		}
+		if err := writeFileAtomically(path, content, 0o644); err != nil {
+			return written, fmt.Errorf("while writing search index %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return
+}
@@ -0,0 +1,71 @@
+package ortfomk
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	ortfodb "github.com/ortfo/db"
+	"github.com/stretchr/testify/assert"
+	v8 "rogchap.com/v8go"
+)
+
+func TestSearchTerms(t *testing.T) {
+	assert.Equal(t, []string{"design", "gam", "run"}, SearchTerms("en", "Designing the Games, running"))
+	assert.Equal(t, []string{"illustr", "journal"}, SearchTerms("fr", "Les illustrations de journaux"))
+	assert.Equal(t, SearchTerms("fr", "Élégance"), SearchTerms("fr", "elegance"))
+	assert.Equal(t, SearchTerms("en", "Dvořák, Erdős and Nguyễn"), SearchTerms("en", "dvorak, erdos and nguyen"))
+}
+
+func TestSearchIndexClient(t *testing.T) {
+	defer SetGlobalData(g)
+	config := DefaultConfiguration()
+	config.Languages = []string{"en"}
+	dist := t.TempDir()
+	SetGlobalData(&GlobalData{Configuration: config, OutputDirectory: dist})
+
+	work := func(id string, title string, paragraph string, tags ...string) Work {
+		w := Work{Metadata: WorkMetadata{Tags: tags}}
+		w.ID = id
+		w.Title = map[string]string{"en": title}
+		w.Paragraphs = map[string][]ortfodb.Paragraph{"en": {{Content: "<p>" + paragraph + "</p>"}}}
+		return w
+	}
+	SetDatabaseOnGlobalData(Database{
+		Works: []Work{
+			work("board-game", "A board game", "Designing rules for players.", "game"),
+			work("poster", "Festival poster", "An illustration designed for a music festival."),
+		},
+		Tags: []Tag{{Singular: "game", Plural: "games"}},
+	})
+	g.addToSitemap(filepath.Join("src", "works", ":work.pug"), &Hydration{language: "en", work: g.Works[0]}, filepath.Join(dist, "en", "works", "board-game.html"))
+
+	index := BuildSearchIndex("en")
+	assert.Equal(t, "works/board-game.html", index.Documents[0].URL)
+	encoded, err := json.Marshal(index)
+	assert.NoError(t, err)
+
+	context := v8.NewContext(v8.NewIsolate())
+	_, err = context.RunScript(fmt.Sprintf(`
+		const location = { href: "https://example.com/en/" }
+		class URL { constructor(url, base) { this.href = base ? (base.href || base).replace(/[^/]*$/, "") + url : url } }
+		const fetch = () => Promise.resolve({ json: () => (%s) })
+		%s
+		const search = searchIndexClient("search-index.json")
+		var results = {}
+		for (const query of ["designing", "gam", "GAMES players", "festival games"]) {
+			search(query).then(found => { results[query] = found.map(({ id, url }) => id + " " + url) })
+		}
+	`, encoded, staticTemplateFunctions), "search.js")
+	assert.NoError(t, err)
+	context.PerformMicrotaskCheckpoint()
+	results, err := context.RunScript("JSON.stringify(results)", "results.js")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"designing": ["board-game https://example.com/en/works/board-game.html", "poster "],
+		"gam": ["board-game https://example.com/en/works/board-game.html"],
+		"GAMES players": ["board-game https://example.com/en/works/board-game.html"],
+		"festival games": []
+	}`, results.String())
+}
@@ -0,0 +1,279 @@
+package ortfomk
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SitemapMaxURLs is the maximum number of URLs a single sitemap file can contain.
+// Sites with more pages get a sitemap index pointing to multiple sitemap files.
+const SitemapMaxURLs = 50_000
+
+// sitemapPage is a page of the site, in all of its languages.
+type sitemapPage struct {
+	// Maps languages to the page's URL in that language
+	URLs map[string]string
+	// Maps languages to the page's output file in that language
+	OutPaths     map[string]string
+	LastModified time.Time
+}
+
+// sitemapCollector collects the pages built during a build, to write the sitemap at the end of it.
+type sitemapCollector struct {
+	mu    sync.Mutex
+	pages map[string]*sitemapPage
+}
+
+// reset forgets all collected pages, before starting a new full build.
+func (s *sitemapCollector) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pages = make(map[string]*sitemapPage)
+}
+
+// add records that the template pageName, rendered with hydration, was built to outPath, which is available at url.
+// url is empty when the site's public URL is not known (see PublicURLOf).
+// modified is when outPath was last written, used as the last modification date of pages that are not about a dated work.
+func (s *sitemapCollector) add(pageName string, hydration *Hydration, outPath string, url string, modified time.Time) {
+	if !strings.HasSuffix(outPath, ".html") {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pages == nil {
+		s.pages = make(map[string]*sitemapPage)
+	}
+	key := pageName + "\x00" + hydrationObjectKey(hydration)
+	page, ok := s.pages[key]
+	if !ok {
+		page = &sitemapPage{URLs: make(map[string]string), OutPaths: make(map[string]string)}
+		s.pages[key] = page
+	}
+	page.OutPaths[hydration.language] = outPath
+	if url != "" {
+		page.URLs[hydration.language] = url
+	}
+	if hydration.IsWork() {
+		work := hydration.work.InLanguage(hydration.language)
+		if created := work.Created(); created.Year() != 9999 {
+			page.LastModified = created
+			return
+		}
+	}
+	if modified.After(page.LastModified) {
+		page.LastModified = modified
+	}
+}
+
+// addToSitemap records that the template pageName, rendered with hydration, was built to outPath.
+func (g *Builder) addToSitemap(pageName string, hydration *Hydration, outPath string) {
+	url, _ := g.PublicURLOf(hydration.language, outPath)
+	// Pages that were up to date keep the modification date of the build that wrote them
+	modified := time.Now()
+	if info, err := os.Stat(outPath); err == nil {
+		modified = info.ModTime()
+	}
+	g.sitemap.add(pageName, hydration, outPath, url, modified)
+}
+
+// urlOf returns the URL of the page about the given work in language.
+// When several templates render pages for the work, the shortest URL is used.
+func (s *sitemapCollector) urlOf(workID string, language string) string {
+	return s.shortestOfWork(workID, func(page *sitemapPage) string { return page.URLs[language] })
+}
+
+// outPathOf returns the output file of the page about the given work in language, chosen like urlOf.
+func (s *sitemapCollector) outPathOf(workID string, language string) string {
+	return s.shortestOfWork(workID, func(page *sitemapPage) string { return page.OutPaths[language] })
+}
+
+func (s *sitemapCollector) shortestOfWork(workID string, value func(page *sitemapPage) string) (shortest string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, page := range s.pages {
+		if !strings.HasSuffix(key, "\x00work:"+workID) {
+			continue
+		}
+		if candidate := value(page); candidate != "" && (shortest == "" || len(candidate) < len(shortest) || len(candidate) == len(shortest) && candidate < shortest) {
+			shortest = candidate
+		}
+	}
+	return
+}
+
+// hydrationObjectKey identifies the object a hydration is about, regardless of its language.
+func hydrationObjectKey(h *Hydration) string {
+	switch {
+	case h.IsWork():
+		return "work:" + h.work.ID
+	case h.IsCollection():
+		return "collection:" + h.collection.ID
+	case h.IsTag():
+		return "tag:" + h.tag.URLName()
+	case h.IsTech():
+		return "technology:" + h.tech.URLName
+	case h.IsSite():
+		return "site:" + h.site.URL
+	}
+	return ""
+}
+
+// PublicURLOf returns the URL at which the given output file is available in production,
+// using the "available at" URL templates of the configuration.
+// ok is false when the configuration does not say where the site is available.
+func (g *Builder) PublicURLOf(language string, outPath string) (url string, ok bool) {
+	availableAt := g.Configuration.Production.AvailableAt
+	relativePath, err := filepath.Rel(g.OutputDirectory, outPath)
+	if err != nil {
+		return "", false
+	}
+	relativePath = filepath.ToSlash(relativePath)
+	if relativePath == "index.html" {
+		relativePath = ""
+	} else {
+		relativePath = strings.TrimSuffix(relativePath, "/index.html")
+	}
+
+	translatedPrefix := strings.Trim(strings.ReplaceAll(g.Configuration.Development.OutputTo.Translated, "<language>", language), "/")
+	if availableAt.Translated != "" && translatedPrefix != "" && (relativePath == translatedPrefix || strings.HasPrefix(relativePath, translatedPrefix+"/")) {
+		return joinURL(strings.ReplaceAll(availableAt.Translated, "<language>", language), strings.TrimPrefix(strings.TrimPrefix(relativePath, translatedPrefix), "/")), true
+	}
+	if availableAt.Rest == "" {
+		return "", false
+	}
+	restPrefix := strings.Trim(g.Configuration.Development.OutputTo.Rest, "/")
+	if restPrefix != "" {
+		if relativePath != restPrefix && !strings.HasPrefix(relativePath, restPrefix+"/") {
+			return "", false
+		}
+		relativePath = strings.TrimPrefix(strings.TrimPrefix(relativePath, restPrefix), "/")
+	}
+	return joinURL(availableAt.Rest, relativePath), true
+}
+
+func joinURL(base string, path string) string {
+	return strings.TrimSuffix(base, "/") + "/" + path
+}
+
+type sitemapURLSet struct {
+	XMLName        xml.Name     `xml:"urlset"`
+	Namespace      string       `xml:"xmlns,attr"`
+	XHTMLNamespace string       `xml:"xmlns:xhtml,attr"`
+	URLs           []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Location     string             `xml:"loc"`
+	LastModified string             `xml:"lastmod,omitempty"`
+	Alternates   []sitemapAlternate `xml:"xhtml:link"`
+}
+
+type sitemapAlternate struct {
+	Rel      string `xml:"rel,attr"`
+	Language string `xml:"hreflang,attr"`
+	URL      string `xml:"href,attr"`
+}
+
+type sitemapIndex struct {
+	XMLName   xml.Name         `xml:"sitemapindex"`
+	Namespace string           `xml:"xmlns,attr"`
+	Sitemaps  []sitemapPointer `xml:"sitemap"`
+}
+
+type sitemapPointer struct {
+	Location string `xml:"loc"`
+}
+
+// urls returns the sitemap entries for every collected page, one per URL, sorted by URL.
+// Pages built to the same file in every language have a single URL, and no alternates.
+func (s *sitemapCollector) urls() []sitemapURL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	urls := make([]sitemapURL, 0, len(s.pages))
+	seen := make(map[string]bool)
+	for _, page := range s.pages {
+		languages := keys(page.URLs)
+		sort.Strings(languages)
+		distinct := make(map[string]bool)
+		for _, language := range languages {
+			distinct[page.URLs[language]] = true
+		}
+		alternates := make([]sitemapAlternate, 0)
+		if len(distinct) > 1 {
+			for _, language := range languages {
+				alternates = append(alternates, sitemapAlternate{Rel: "alternate", Language: language, URL: page.URLs[language]})
+			}
+		}
+		lastModified := ""
+		if !page.LastModified.IsZero() {
+			lastModified = page.LastModified.Format("2006-01-02")
+		}
+		for _, language := range languages {
+			if seen[page.URLs[language]] {
+				continue
+			}
+			seen[page.URLs[language]] = true
+			urls = append(urls, sitemapURL{
+				Location:     page.URLs[language],
+				LastModified: lastModified,
+				Alternates:   alternates,
+			})
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool { return urls[i].Location < urls[j].Location })
+	return urls
+}
+
+// WriteSitemap writes sitemap.xml with every page built, next to the site's other non-translated files.
+// When there are more than SitemapMaxURLs pages, sitemap.xml is a sitemap index and the pages are split into sitemap-1.xml, sitemap-2.xml, etc.
+// It returns the paths of the files written.
+func (g *Builder) WriteSitemap() (written []string, err error) {
+	if g.Configuration.Production.AvailableAt.Rest == "" {
+		g.LogDebug("not writing a sitemap: the configuration does not say where the site is available at")
+		return
+	}
+	urls := g.sitemap.urls()
+	directory := filepath.Join(g.OutputDirectory, g.Configuration.Development.OutputTo.Rest)
+	indexPath := filepath.Join(directory, "sitemap.xml")
+
+	if len(urls) <= SitemapMaxURLs {
+		return []string{indexPath}, writeXML(indexPath, newSitemapURLSet(urls))
+	}
+
+	index := sitemapIndex{Namespace: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for start := 0; start < len(urls); start += SitemapMaxURLs {
+		end := start + SitemapMaxURLs
+		if end > len(urls) {
+			end = len(urls)
+		}
+		filename := fmt.Sprintf("sitemap-%d.xml", len(index.Sitemaps)+1)
+		if err := writeXML(filepath.Join(directory, filename), newSitemapURLSet(urls[start:end])); err != nil {
+			return written, err
+		}
+		written = append(written, filepath.Join(directory, filename))
+		index.Sitemaps = append(index.Sitemaps, sitemapPointer{Location: joinURL(g.Configuration.Production.AvailableAt.Rest, filename)})
+	}
+	return append(written, indexPath), writeXML(indexPath, index)
+}
+
+func newSitemapURLSet(urls []sitemapURL) sitemapURLSet {
+	return sitemapURLSet{
+		Namespace:      "http://www.sitemaps.org/schemas/sitemap/0.9",
+		XHTMLNamespace: "http://www.w3.org/1999/xhtml",
+		URLs:           urls,
+	}
+}
+
+func writeXML(path string, document interface{}) error {
+	content, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return fmt.Errorf("while encoding %s: %w", path, err)
+	}
+	return writeFileAtomically(path, append([]byte(xml.Header), content...), 0o644)
+}
@@ -0,0 +1,68 @@
+package ortfomk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicURLOf(t *testing.T) {
+	defer SetGlobalData(g)
+	config := DefaultConfiguration()
+	config.Production.AvailableAt = OutputTemplates{Translated: "https://<language>.example.com/", Rest: "https://example.com/", Media: "https://media.example.com/"}
+	SetGlobalData(&GlobalData{Configuration: config, OutputDirectory: "dist"})
+
+	url, ok := PublicURLOf("fr", filepath.Join("dist", "fr", "works", "index.html"))
+	assert.True(t, ok)
+	assert.Equal(t, "https://fr.example.com/works", url)
+
+	url, ok = PublicURLOf("fr", filepath.Join("dist", "fr", "index.html"))
+	assert.True(t, ok)
+	assert.Equal(t, "https://fr.example.com/", url)
+
+	url, ok = PublicURLOf("en", filepath.Join("dist", "resume.html"))
+	assert.True(t, ok)
+	assert.Equal(t, "https://example.com/resume.html", url)
+}
+
+func TestWriteSitemap(t *testing.T) {
+	defer SetGlobalData(g)
+	config := DefaultConfiguration()
+	config.Production.AvailableAt = OutputTemplates{Translated: "https://<language>.example.com/", Rest: "https://example.com/"}
+	dist := t.TempDir()
+	SetGlobalData(&GlobalData{Configuration: config, OutputDirectory: dist})
+
+	for _, language := range []string{"fr", "en"} {
+		g.addToSitemap(filepath.Join("src", "about.pug"), &Hydration{language: language}, filepath.Join(dist, language, "about.html"))
+	}
+
+	written, err := WriteSitemap()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dist, "sitemap.xml")}, written)
+	content, _ := os.ReadFile(written[0])
+	assert.Contains(t, string(content), `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">`)
+	assert.Contains(t, string(content), `<loc>https://fr.example.com/about.html</loc>`)
+	assert.Contains(t, string(content), `<xhtml:link rel="alternate" hreflang="en" href="https://en.example.com/about.html"></xhtml:link>`)
+}
+
+func TestSitemapHasPagesBuiltInEveryLanguageOnce(t *testing.T) {
+	defer SetGlobalData(g)
+	config := DefaultConfiguration()
+	config.Production.AvailableAt = OutputTemplates{Translated: "https://<language>.example.com/", Rest: "https://example.com/"}
+	dist := t.TempDir()
+	SetGlobalData(&GlobalData{Configuration: config, OutputDirectory: dist})
+	resume := filepath.Join(dist, "resume.html")
+	assert.NoError(t, os.WriteFile(resume, []byte("resume"), 0o644))
+	modified := time.Date(2022, time.May, 3, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, os.Chtimes(resume, modified, modified))
+
+	for _, language := range []string{"fr", "en"} {
+		g.addToSitemap(filepath.Join("src", "resume.pug"), &Hydration{language: language}, resume)
+	}
+
+	urls := g.sitemap.urls()
+	assert.Equal(t, []sitemapURL{{Location: "https://example.com/resume.html", LastModified: "2022-05-03", Alternates: []sitemapAlternate{}}}, urls)
+}
@@ -0,0 +1,168 @@
+package ortfomk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GeneratedJSFile is the JavaScript file that is run to render a page.
+// It keeps track of where each of its sections (prelude, data, compiled pug template, etc.) are,
+// so that errors can be traced back to their origin.
+//
+// Only the page's own code and data is in Content: the prelude, static template functions and SharedData
+// are evaluated once per context and re-used across pages (see JSRuntime).
+type GeneratedJSFile struct {
+	Content  string
+	Sections []GeneratedJSSection
+	// Language the page is rendered in
+	Language string
+	Prelude  string
+	// Language-wide data the page references
+	SharedData []*SharedData
+	// Names of the globals Content defines
+	Globals []string
+}
+
+// GeneratedJSSection is a part of a GeneratedJSFile, from byte offset Start (inclusive) to End (exclusive).
+type GeneratedJSSection struct {
+	Name  string
+	Start int
+	End   int
+}
+
+const (
+	SectionPrelude                 = "prelude"
+	SectionData                    = "data"
+	SectionStaticTemplateFunctions = "static template functions"
+	SectionCompiledPugTemplate     = "compiled pug template"
+	SectionTemplateCall            = "template call"
+)
+
+var (
+	pugDebugLinePattern     = regexp.MustCompile(`pug_debug_line = (\d+);`)
+	pugDebugFilenamePattern = regexp.MustCompile(`pug_debug_filename = ("(?:[^"\\]|\\.)*");`)
+)
+
+func (f *GeneratedJSFile) appendSection(name string, content string) {
+	f.Sections = append(f.Sections, GeneratedJSSection{
+		Name:  name,
+		Start: len(f.Content),
+		End:   len(f.Content) + len(content),
+	})
+	f.Content += content
+}
+
+// Offset returns the byte offset of the given 1-based line and column, or -1 if it is out of the file.
+func (f GeneratedJSFile) Offset(line uint64, column uint64) int {
+	if line == 0 {
+		return -1
+	}
+	offset := 0
+	for currentLine := uint64(1); currentLine < line; currentLine++ {
+		newline := strings.IndexByte(f.Content[offset:], '\n')
+		if newline < 0 {
+			return -1
+		}
+		offset += newline + 1
+	}
+	if column > 0 {
+		offset += int(column) - 1
+	}
+	if offset > len(f.Content) {
+		return -1
+	}
+	return offset
+}
+
+// SectionAt returns the section the given byte offset is in.
+func (f GeneratedJSFile) SectionAt(offset int) (GeneratedJSSection, bool) {
+	for _, section := range f.Sections {
+		if offset >= section.Start && offset < section.End {
+			return section, true
+		}
+	}
+	return GeneratedJSSection{}, false
+}
+
+// PugLocation maps the given position in the generated file back to the pug file and line it was compiled from,
+// using the pug_debug_line and pug_debug_filename markers that pug adds before each compiled statement.
+// ok is false when the position is not inside the compiled pug template, or when no marker precedes it.
+func (f GeneratedJSFile) PugLocation(line uint64, column uint64) (filename string, pugLine int, ok bool) {
+	offset := f.Offset(line, column)
+	section, found := f.SectionAt(offset)
+	if !found || section.Name != SectionCompiledPugTemplate {
+		return "", 0, false
+	}
+	precedingCode := f.Content[section.Start:offset]
+
+	lineMarkers := pugDebugLinePattern.FindAllStringSubmatch(precedingCode, -1)
+	if len(lineMarkers) == 0 {
+		return "", 0, false
+	}
+	pugLine, err := strconv.Atoi(lineMarkers[len(lineMarkers)-1][1])
+	if err != nil {
+		return "", 0, false
+	}
+
+	filenameMarkers := pugDebugFilenamePattern.FindAllStringSubmatch(precedingCode, -1)
+	if len(filenameMarkers) > 0 {
+		filename, err = strconv.Unquote(filenameMarkers[len(filenameMarkers)-1][1])
+		if err != nil {
+			return "", 0, false
+		}
+	}
+	return filename, pugLine, true
+}
+
+// stackFramePositions returns the (line, column) positions of every frame of stackTrace that is in the given script.
+// Frames are returned innermost first, as in the stack trace.
+func stackFramePositions(stackTrace string, scriptName string) (positions [][2]uint64) {
+	pattern := regexp.MustCompile(regexp.QuoteMeta(scriptName) + `:(\d+):(\d+)`)
+	for _, match := range pattern.FindAllStringSubmatch(stackTrace, -1) {
+		line, lineErr := strconv.ParseUint(match[1], 10, 64)
+		column, columnErr := strconv.ParseUint(match[2], 10, 64)
+		if lineErr == nil && columnErr == nil {
+			positions = append(positions, [2]uint64{line, column})
+		}
+	}
+	return
+}
+
+// locatePugError fills the pug-related fields of err by walking its stack trace from the innermost frame,
+// stopping at the first one that maps back to a pug template.
+// This way, errors thrown by static template functions are reported where the template calls them.
+func (err *TemplateError) locatePugError(file GeneratedJSFile, scriptName string) {
+	line, column := lineAndColumn(err.JSError)
+	positions := append(stackFramePositions(err.StackTrace, scriptName), [2]uint64{line, column})
+	for _, position := range positions {
+		filename, pugLine, ok := file.PugLocation(position[0], position[1])
+		if !ok {
+			continue
+		}
+		err.PugFile = filename
+		err.PugLine = pugLine
+		if source, readErr := os.ReadFile(filename); readErr == nil {
+			err.PugSnippet = codeSpinnetAround(string(source), uint64(pugLine), 0)
+		}
+		return
+	}
+}
+
+// PugLocation returns a human-readable "file:line" location of the error in the pug template,
+// or "" if it could not be determined.
+func (err *TemplateError) PugLocation() string {
+	if err.PugFile == "" {
+		return ""
+	}
+	displayed := err.PugFile
+	if cwd, cwdErr := os.Getwd(); cwdErr == nil {
+		if relative, relErr := filepath.Rel(cwd, err.PugFile); relErr == nil {
+			displayed = relative
+		}
+	}
+	return fmt.Sprintf("%s:%d", displayed, err.PugLine)
+}
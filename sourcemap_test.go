@@ -0,0 +1,58 @@
+package ortfomk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v8 "rogchap.com/v8go"
+)
+
+func TestGeneratedJSFilePugLocation(t *testing.T) {
+	file := GeneratedJSFile{}
+	file.appendSection(SectionPrelude, "const a = 1\n")
+	file.appendSection(SectionCompiledPugTemplate, `function template(locals) {
+;pug_debug_line = 1;pug_debug_filename = "src/page.pug";
+pug_html = pug_html + "a";
+;pug_debug_line = 4;pug_debug_filename = "src/mixins/card.pug";
+pug_html = pug_html + locals.oops.boom;
+}
+`)
+	file.appendSection(SectionTemplateCall, "template({})")
+
+	filename, line, ok := file.PugLocation(6, 28)
+	assert.True(t, ok)
+	assert.Equal(t, "src/mixins/card.pug", filename)
+	assert.Equal(t, 4, line)
+
+	filename, line, ok = file.PugLocation(4, 1)
+	assert.True(t, ok)
+	assert.Equal(t, "src/page.pug", filename)
+	assert.Equal(t, 1, line)
+
+	_, _, ok = file.PugLocation(1, 1)
+	assert.False(t, ok)
+}
+
+func TestTemplateErrorLocatesPugLine(t *testing.T) {
+	pugFile := filepath.Join(t.TempDir(), "page.pug")
+	os.WriteFile(pugFile, []byte("p hello\np= oops.boom\n"), 0644)
+
+	file := GeneratedJSFile{}
+	file.appendSection(SectionStaticTemplateFunctions, "function explode(v) { return v.boom }\n")
+	file.appendSection(SectionCompiledPugTemplate, "function template() {\n;pug_debug_line = 2;pug_debug_filename = "+strconv.Quote(pugFile)+";\nreturn explode(undefined)\n}\n")
+	file.appendSection(SectionTemplateCall, "template()")
+
+	_, err := v8.NewContext(v8.NewIsolate()).RunScript(file.Content, "page.pug.js")
+	var jsErr *v8.JSError
+	assert.True(t, errors.As(err, &jsErr))
+
+	templateErr := &TemplateError{JSError: jsErr}
+	templateErr.locatePugError(file, "page.pug.js")
+	assert.Equal(t, pugFile, templateErr.PugFile)
+	assert.Equal(t, 2, templateErr.PugLine)
+	assert.Contains(t, templateErr.PugSnippet, ">   2 | p= oops.boom")
+}
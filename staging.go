@@ -0,0 +1,171 @@
+package ortfomk
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// StagedOutput is an output directory that is built into a staging directory next to it,
+// and swapped into place once the build succeeded, so that the output directory never has a mixture of old and new pages.
+// The previous output directory is kept as a rollback copy.
+type StagedOutput struct {
+	// Destination is the output directory
+	Destination string
+	// Staging is the directory to build into, until Commit or Abort is called
+	Staging string
+	// Previous is where Commit moves the output directory of the previous build to
+	Previous string
+	// Files put back as they were by Abort, see RestoreOnAbort
+	restoreOnAbort []savedFile
+	committed      bool
+}
+
+// savedFile is the content of a file at some point, or the fact that it did not exist.
+type savedFile struct {
+	path    string
+	content []byte
+	existed bool
+}
+
+// StageOutput prepares a staging directory to build destination in.
+// Unless clean is true, the staging directory starts as a copy of destination, so that pages that are up to date (see BuildManifest) need not be rebuilt.
+// The copy is made of hard links where possible: this works because output files are never modified in place, but replaced (see writeFileAtomically).
+func StageOutput(destination string, clean bool) (*StagedOutput, error) {
+	destination, err := filepath.Abs(destination)
+	if err != nil {
+		return nil, fmt.Errorf("while getting absolute path of %s: %w", destination, err)
+	}
+	staged := &StagedOutput{
+		Destination: destination,
+		Staging:     destination + ".staging",
+		Previous:    destination + ".previous",
+	}
+	// Left over by a build that was killed
+	if err := os.RemoveAll(staged.Staging); err != nil {
+		return nil, fmt.Errorf("while removing the previous staging directory: %w", err)
+	}
+	if _, err := os.Stat(destination); clean || os.IsNotExist(err) {
+		return staged, os.MkdirAll(staged.Staging, 0o777)
+	}
+	if err := linkTree(destination, staged.Staging); err != nil {
+		os.RemoveAll(staged.Staging)
+		return nil, fmt.Errorf("while copying %s to the staging directory: %w", destination, err)
+	}
+	return staged, nil
+}
+
+// Commit replaces the output directory with the staging directory, and moves the output directory of the previous build to Previous,
+// replacing the rollback copy of the build before.
+// Where the system supports it (see exchangeDirectories), both directories are swapped atomically.
+// Elsewhere, the output directory does not exist in-between two renames.
+func (s *StagedOutput) Commit() error {
+	if err := os.RemoveAll(s.Previous); err != nil {
+		return fmt.Errorf("while removing the previous rollback copy: %w", err)
+	}
+	if _, err := os.Stat(s.Destination); err == nil {
+		if err := exchangeDirectories(s.Staging, s.Destination); err == nil {
+			s.committed = true
+			// The staging directory now holds the previous build
+			if err := os.Rename(s.Staging, s.Previous); err != nil {
+				return fmt.Errorf("while moving the previous build to %s: %w", s.Previous, err)
+			}
+			return nil
+		}
+	}
+	if err := os.Rename(s.Destination, s.Previous); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("while moving %s to %s: %w", s.Destination, s.Previous, err)
+	}
+	if err := os.Rename(s.Staging, s.Destination); err != nil {
+		// Put the previous build back in place
+		os.Rename(s.Previous, s.Destination)
+		return fmt.Errorf("while moving %s to %s: %w", s.Staging, s.Destination, err)
+	}
+	s.committed = true
+	return nil
+}
+
+// RestoreOnAbort saves the current content of the file at path, for Abort to put it back.
+// This is used for the build manifest: it describes the staging directory's pages once built,
+// which are not the pages of the output directory if the staging directory is thrown away.
+func (s *StagedOutput) RestoreOnAbort(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("while reading %s: %w", path, err)
+	}
+	s.restoreOnAbort = append(s.restoreOnAbort, savedFile{path: path, content: content, existed: err == nil})
+	return nil
+}
+
+// Abort removes the staging directory, leaving the output directory as it was, and restores the files saved with RestoreOnAbort.
+// It does nothing once Commit succeeded.
+func (s *StagedOutput) Abort() error {
+	if s.committed {
+		return nil
+	}
+	for _, file := range s.restoreOnAbort {
+		var err error
+		if file.existed {
+			err = writeFileAtomically(file.path, file.content, 0o644)
+		} else {
+			err = os.Remove(file.path)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("while restoring %s: %w", file.path, err)
+		}
+	}
+	return os.RemoveAll(s.Staging)
+}
+
+// linkTree re-creates the directory tree from at to, hard-linking files, or copying them when they can't be linked.
+func linkTree(from string, to string) error {
+	return filepath.WalkDir(from, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, relative)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&fs.ModeSymlink != 0:
+			destination, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(destination, target)
+		default:
+			if err := os.Link(path, target); err == nil {
+				return nil
+			}
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+// copyFile copies the file at from to a new file at to.
+func copyFile(from string, to string, perm fs.FileMode) error {
+	source, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	destination, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(destination, source); err != nil {
+		destination.Close()
+		return err
+	}
+	return destination.Close()
+}
@@ -0,0 +1,9 @@
+package ortfomk
+
+import "golang.org/x/sys/unix"
+
+// exchangeDirectories atomically swaps the directories at a and b.
+// This fails on filesystems that don't support renameat2's RENAME_EXCHANGE flag.
+func exchangeDirectories(a string, b string) error {
+	return unix.Renameat2(unix.AT_FDCWD, a, unix.AT_FDCWD, b, unix.RENAME_EXCHANGE)
+}
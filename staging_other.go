@@ -0,0 +1,11 @@
+//go:build !linux
+
+package ortfomk
+
+import "errors"
+
+// exchangeDirectories atomically swaps the directories at a and b.
+// This is only supported on Linux.
+func exchangeDirectories(a string, b string) error {
+	return errors.New("exchanging directories is not supported on this system")
+}
@@ -0,0 +1,70 @@
+package ortfomk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStageOutput(t *testing.T) {
+	dist := filepath.Join(t.TempDir(), "dist")
+	page := filepath.Join("en", "index.html")
+	os.MkdirAll(filepath.Join(dist, "en"), 0755)
+	os.WriteFile(filepath.Join(dist, page), []byte("old"), 0644)
+
+	staged, err := StageOutput(dist, false)
+	assert.NoError(t, err)
+	assert.Equal(t, dist+".staging", staged.Staging)
+	content, err := os.ReadFile(filepath.Join(staged.Staging, page))
+	assert.NoError(t, err)
+	assert.Equal(t, "old", string(content))
+
+	// Building into the staging directory leaves the output directory as it was
+	assert.NoError(t, writeFileAtomically(filepath.Join(staged.Staging, page), []byte("new"), 0o644))
+	content, _ = os.ReadFile(filepath.Join(dist, page))
+	assert.Equal(t, "old", string(content))
+
+	assert.NoError(t, staged.Commit())
+	assert.NoDirExists(t, staged.Staging)
+	content, _ = os.ReadFile(filepath.Join(dist, page))
+	assert.Equal(t, "new", string(content))
+	content, _ = os.ReadFile(filepath.Join(staged.Previous, page))
+	assert.Equal(t, "old", string(content))
+
+	// Committing again replaces the rollback copy
+	staged, err = StageOutput(dist, true)
+	assert.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(staged.Staging, page))
+	assert.NoError(t, staged.Commit())
+	assert.NoFileExists(t, filepath.Join(dist, page))
+	content, _ = os.ReadFile(filepath.Join(staged.Previous, page))
+	assert.Equal(t, "new", string(content))
+}
+
+func TestStagedOutputAbort(t *testing.T) {
+	dist := filepath.Join(t.TempDir(), "dist")
+	os.MkdirAll(dist, 0755)
+	os.WriteFile(filepath.Join(dist, "index.html"), []byte("old"), 0644)
+
+	manifest := filepath.Join(t.TempDir(), "manifest.json")
+	os.WriteFile(manifest, []byte("old"), 0644)
+	created := filepath.Join(t.TempDir(), "created.json")
+
+	staged, err := StageOutput(dist, false)
+	assert.NoError(t, err)
+	assert.NoError(t, staged.RestoreOnAbort(manifest))
+	assert.NoError(t, staged.RestoreOnAbort(created))
+	assert.NoError(t, writeFileAtomically(filepath.Join(staged.Staging, "index.html"), []byte("new"), 0o644))
+	assert.NoError(t, writeFileAtomically(manifest, []byte("new"), 0o644))
+	assert.NoError(t, writeFileAtomically(created, []byte("new"), 0o644))
+	assert.NoError(t, staged.Abort())
+	assert.NoDirExists(t, staged.Staging)
+	assert.NoDirExists(t, staged.Previous)
+	content, _ := os.ReadFile(filepath.Join(dist, "index.html"))
+	assert.Equal(t, "old", string(content))
+	content, _ = os.ReadFile(manifest)
+	assert.Equal(t, "old", string(content))
+	assert.NoFileExists(t, created)
+}
@@ -0,0 +1,119 @@
+package ortfomk
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// StaleOutputs returns the output files that were produced by the previous build but not by the current one.
+func (m *BuildManifest) StaleOutputs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stale := make([]string, 0)
+	for outPath := range m.previous {
+		if _, built := m.Pages[outPath]; !built {
+			stale = append(stale, outPath)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// KeepPrevious carries the previous build's entry for outPath over to the current build, if there is one.
+// This is used for pages that failed to build, or for stale outputs that are not removed,
+// so that their output files are still known as generated by ortfomk.
+func (m *BuildManifest) KeepPrevious(outPath string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry, ok := m.previous[outPath]; ok {
+		if _, built := m.Pages[outPath]; !built {
+			m.Pages[outPath] = entry
+		}
+	}
+}
+
+// KeepPreviousOf carries over the previous build's entries of all pages built from the given template,
+// for templates that failed to build altogether, and for which the output files are therefore not known.
+func (m *BuildManifest) KeepPreviousOf(source string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for outPath, entry := range m.previous {
+		if _, built := m.Pages[outPath]; !built && entry.Source == source {
+			m.Pages[outPath] = entry
+		}
+	}
+}
+
+// forgetPrevious drops the previous build's entries.
+func (m *BuildManifest) forgetPrevious() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.previous = make(map[string]BuildManifestEntry)
+}
+
+// RemoveStaleOutputs deletes files that were produced by the previous build but not by the current one
+// (e.g. pages of a work that was renamed). Files that ortfomk did not generate are left alone.
+// When dryRun is true, stale files are only listed.
+func (g *Builder) RemoveStaleOutputs(manifest *BuildManifest, dryRun bool) (stale []string) {
+	stale = manifest.StaleOutputs()
+	for _, outPath := range stale {
+		if dryRun {
+			g.LogInfo("Stale output file: %s", outPath)
+			manifest.KeepPrevious(outPath)
+			continue
+		}
+		for _, file := range producedFiles(outPath) {
+			g.LogDebug("removing stale output file %s", file)
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				g.LogWarning("couldn't remove stale output file %s: %s", file, err)
+				continue
+			}
+			removeEmptyParents(file, g.distDirectory())
+		}
+	}
+	if !dryRun {
+		manifest.forgetPrevious()
+	}
+	if len(stale) > 0 {
+		if dryRun {
+			g.LogInfo("%d stale output files would be removed", len(stale))
+		} else {
+			g.LogInfo("Removed %d stale output files", len(stale))
+		}
+	}
+	return
+}
+
+// producedFiles returns the files written when building outPath: PDF pages also leave their HTML source next to them.
+func producedFiles(outPath string) []string {
+	if strings.HasSuffix(outPath, ".pdf") {
+		return []string{outPath, strings.TrimSuffix(outPath, ".pdf") + ".html"}
+	}
+	return []string{outPath}
+}
+
+// removeEmptyParents removes the directories containing file that became empty, up to (but excluding) the output directory outDir.
+// Nothing is removed for files outside of outDir.
+func removeEmptyParents(file string, outDir string) {
+	outDir, err := filepath.Abs(outDir)
+	if err != nil {
+		return
+	}
+	file, err = filepath.Abs(file)
+	if err != nil {
+		return
+	}
+	for directory := filepath.Dir(file); isInside(directory, outDir); directory = filepath.Dir(directory) {
+		if os.Remove(directory) != nil {
+			return
+		}
+	}
+}
+
+// isInside returns whether path is strictly inside of directory. Both paths must be absolute.
+func isInside(path string, directory string) bool {
+	relative, err := filepath.Rel(directory, path)
+	return err == nil && relative != "." && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
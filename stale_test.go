@@ -0,0 +1,56 @@
+package ortfomk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveStaleOutputs(t *testing.T) {
+	root := t.TempDir()
+	dist := filepath.Join(root, "dist")
+	builder := NewBuilder(filepath.Join(root, "src"), dist, DefaultConfiguration(), Flags{})
+	renamed := filepath.Join(dist, "en", "old-work", "index.html")
+	kept := filepath.Join(dist, "en", "index.html")
+	media := filepath.Join(dist, "media", "picture.png")
+	for _, file := range []string{renamed, kept, media} {
+		os.MkdirAll(filepath.Dir(file), 0755)
+		os.WriteFile(file, []byte("content"), 0644)
+	}
+
+	manifest := NewBuildManifest()
+	manifest.Record(renamed, BuildManifestEntry{}, false)
+	manifest.Record(kept, BuildManifestEntry{}, false)
+	manifest.StartBuild()
+	manifest.Record(kept, BuildManifestEntry{}, true)
+
+	assert.Equal(t, []string{renamed}, builder.RemoveStaleOutputs(manifest, true))
+	assert.FileExists(t, renamed)
+
+	manifest.StartBuild()
+	manifest.Record(kept, BuildManifestEntry{}, true)
+	assert.Equal(t, []string{renamed}, builder.RemoveStaleOutputs(manifest, false))
+	assert.NoFileExists(t, renamed)
+	assert.NoDirExists(t, filepath.Dir(renamed))
+	assert.FileExists(t, kept)
+	assert.FileExists(t, media)
+	assert.Empty(t, manifest.StaleOutputs())
+}
+
+func TestRemoveEmptyParentsStopsAtTheOutputDirectory(t *testing.T) {
+	root := t.TempDir()
+	dist := filepath.Join(root, "dist")
+	file := filepath.Join(dist, "en", "works", "index.html")
+	os.MkdirAll(filepath.Dir(file), 0755)
+
+	removeEmptyParents(file, dist)
+	assert.NoDirExists(t, filepath.Join(dist, "en"))
+	assert.DirExists(t, dist)
+
+	outside := filepath.Join(root, "elsewhere", "index.html")
+	os.MkdirAll(filepath.Dir(outside), 0755)
+	removeEmptyParents(outside, dist)
+	assert.DirExists(t, filepath.Dir(outside))
+}
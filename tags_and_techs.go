@@ -69,8 +69,8 @@ func (t *Technology) ReferredToBy(name string) bool {
 }
 
 // LoadTechnologies loads the technologies from the given yaml file into a []Technology
-func LoadTechnologies(filename string) (technologies []Technology, err error) {
-	Status(StepLoadTechnologies, ProgressDetails{File: filename})
+func (g *Builder) LoadTechnologies(filename string) (technologies []Technology, err error) {
+	g.Status(StepLoadTechnologies, ProgressDetails{File: filename})
 	raw, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return
@@ -83,8 +83,8 @@ func LoadTechnologies(filename string) (technologies []Technology, err error) {
 }
 
 // LoadExternalSites loads the sites from the given yaml file into a []Site
-func LoadExternalSites(filename string) (sites []ExternalSite, err error) {
-	Status(StepLoadExternalSites, ProgressDetails{File: filename})
+func (g *Builder) LoadExternalSites(filename string) (sites []ExternalSite, err error) {
+	g.Status(StepLoadExternalSites, ProgressDetails{File: filename})
 	raw, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return
@@ -97,8 +97,8 @@ func LoadExternalSites(filename string) (sites []ExternalSite, err error) {
 }
 
 // LoadTags loads the tags from the given yaml file into a []Tag
-func LoadTags(filename string) (tags []Tag, err error) {
-	Status(StepLoadTags, ProgressDetails{File: filename})
+func (g *Builder) LoadTags(filename string) (tags []Tag, err error) {
+	g.Status(StepLoadTags, ProgressDetails{File: filename})
 	raw, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return
@@ -4,6 +4,8 @@ import (
 	_ "embed"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -97,7 +99,7 @@ type layedOutElementFrozen struct {
 	String string
 }
 
-func GenerateJSFile(hydration *Hydration, templateName string, compiledPugTemplate string) (string, error) {
+func (g *Builder) GenerateJSFile(hydration *Hydration, templateName string, compiledPugTemplate string) (GeneratedJSFile, error) {
 	var assetsTemplate string
 	var mediaTemplate string
 
@@ -109,121 +111,174 @@ func GenerateJSFile(hydration *Hydration, templateName string, compiledPugTempla
 		mediaTemplate = g.Configuration.Production.AvailableAt.Media
 	}
 
+	// The prelude only depends on the environment and configuration, so it is run once per context (see JSRuntime)
 	prelude := fmt.Sprintf(`
 		const media = path => (%q +"/"+ path)
 		const asset = path => (%q +"/"+ path)
 		const [TRANSLATION_STRING_DELIMITER_OPEN, TRANSLATION_STRING_DELIMITER_CLOSE] = [%q, %q]
 	`, mediaTemplate, assetsTemplate, TranslationStringDelimiterOpen, TranslationStringDelimiterClose)
 
-	dataToInject := map[string]interface{}{
-		"all_tags": func() []tagFrozen {
+	// Data is computed only if the template references it (see ReferencedGlobals).
+	// sharedData is the same for every page of a language, and is serialized once per language (see SharedData),
+	// while dataToInject is specific to the page.
+	sharedData := map[string]func() (interface{}, error){
+		"all_tags": func() (interface{}, error) {
 			frozenTags := make([]tagFrozen, len(g.Tags))
 			for _, tag := range g.Tags {
 				frozenTags = append(frozenTags, tag.Freeze())
 			}
-			return frozenTags
-		}(),
-		"all_technologies": g.Technologies,
-		"all_sites":        g.Sites,
-		"all_works": func() []interface{} {
+			return frozenTags, nil
+		},
+		"all_technologies": func() (interface{}, error) { return g.Technologies, nil },
+		"all_sites":        func() (interface{}, error) { return g.Sites, nil },
+		"all_works": func() (interface{}, error) {
 			works := make([]interface{}, len(g.PublicWorks()))
 			for i, work := range g.PublicWorks() {
 				works[i] = work.InLanguage(hydration.language).Freeze()
 			}
-			return works
-		}(),
-		"all_collections": func() []collectionOneLangFrozen {
+			return works, nil
+		},
+		"all_collections": func() (interface{}, error) {
 			frozenCollections := make([]collectionOneLangFrozen, len(g.Collections))
 			for _, collection := range g.Collections {
 				frozenCollections = append(frozenCollections, collection.InLanguage(hydration.language).Freeze())
 			}
-			return frozenCollections
-		}(),
-		"_translations": func() map[string]string {
+			return frozenCollections, nil
+		},
+		"_translations": func() (interface{}, error) {
 			out := make(map[string]string)
 			for _, message := range g.Translations[hydration.language].poFile.Messages {
 				out[message.MsgId+message.MsgContext] = message.MsgStr
 			}
-			return out
-		}(),
-		"current_language": hydration.language,
-		"current_path": func() string {
-			p, err := hydration.GetDistFilepath(templateName)
+			return out, nil
+		},
+	}
+	dataToInject := map[string]func() (interface{}, error){
+		"current_language": func() (interface{}, error) { return hydration.language, nil },
+		"current_path": func() (interface{}, error) {
+			p, err := g.GetDistFilepath(hydration, templateName)
+			if err != nil {
+				g.LogError("Could not get dist filepath for template %s, this shouldn't happen.", templateName)
+			}
+			return g.relativeToDistDirectory(p), nil
+		},
+		"search_index_url": func() (interface{}, error) {
+			p, _ := g.GetDistFilepath(hydration, templateName)
+			indexPath := filepath.Join(strings.ReplaceAll(g.Configuration.Development.OutputTo.Translated, "<language>", hydration.language), SearchIndexFilename)
+			url, err := filepath.Rel(filepath.Dir(g.relativeToDistDirectory(p)), indexPath)
 			if err != nil {
-				LogError("Could not get dist filepath for template %s, this shouldn't happen.", templateName)
+				return "/" + filepath.ToSlash(indexPath), nil
 			}
-			return strings.TrimPrefix(p, "dist/")
-		}(),
+			return filepath.ToSlash(url), nil
+		},
 	}
 
 	if hydration.IsTag() {
-		dataToInject["CurrentTag"] = hydration.tag.Freeze()
+		dataToInject["CurrentTag"] = func() (interface{}, error) { return hydration.tag.Freeze(), nil }
 	}
 	if hydration.IsTech() {
-		dataToInject["CurrentTech"] = hydration.tech
+		dataToInject["CurrentTech"] = func() (interface{}, error) { return hydration.tech, nil }
 	}
 	if hydration.IsSite() {
-		dataToInject["CurrentSite"] = hydration.site
+		dataToInject["CurrentSite"] = func() (interface{}, error) { return hydration.site, nil }
 	}
 	if hydration.IsCollection() {
-		collection := hydration.collection.InLanguage(hydration.language)
-		dataToInject["CurrentCollection"] = collection.Freeze()
+		dataToInject["CurrentCollection"] = func() (interface{}, error) {
+			return hydration.collection.InLanguage(hydration.language).Freeze(), nil
+		}
 	}
 	if hydration.IsWork() {
 		work := hydration.work.InLanguage(hydration.language)
-		dataToInject["CurrentWork"] = work.Freeze()
-		layedout, err := work.LayedOut()
-		if err != nil {
-			return "", fmt.Errorf("while laying out %s: %w", hydration.Name(), err)
-		}
+		dataToInject["CurrentWork"] = func() (interface{}, error) { return work.Freeze(), nil }
+		dataToInject["CurrentWorkLayedOut"] = func() (interface{}, error) {
+			layedout, err := work.LayedOut()
+			if err != nil {
+				return nil, fmt.Errorf("while laying out %s: %w", hydration.Name(), err)
+			}
 
-		frozenLayout := make([]layedOutElementFrozen, len(layedout))
-		for i, element := range layedout {
-			frozenLayout[i] = layedOutElementFrozen{
-				Type:               element.Type,
-				LayoutIndex:        element.LayoutIndex,
-				Positions:          element.Positions,
-				GeneralContentType: element.GeneralContentType,
-				Title:              element.Title(),
-				ID:                 element.ID(),
-				CSS:                element.CSS(),
-				String:             element.String(),
-				Alt:                element.Alt,
-				Source:             element.Source,
-				Path:               element.Path,
-				ContentType:        element.ContentType,
-				Size:               element.Size,
-				Dimensions:         element.Dimensions,
-				Duration:           element.Duration,
-				Online:             element.Online,
-				Attributes:         element.Attributes,
-				HasSound:           element.HasSound,
-				Content:            element.Content,
-				Name:               element.Name,
-				URL:                element.URL,
-				Metadata:           hydration.work.Metadata,
+			frozenLayout := make([]layedOutElementFrozen, len(layedout))
+			for i, element := range layedout {
+				frozenLayout[i] = layedOutElementFrozen{
+					Type:               element.Type,
+					LayoutIndex:        element.LayoutIndex,
+					Positions:          element.Positions,
+					GeneralContentType: element.GeneralContentType,
+					Title:              element.Title(),
+					ID:                 element.ID(),
+					CSS:                element.CSS(),
+					String:             element.String(),
+					Alt:                element.Alt,
+					Source:             element.Source,
+					Path:               element.Path,
+					ContentType:        element.ContentType,
+					Size:               element.Size,
+					Dimensions:         element.Dimensions,
+					Duration:           element.Duration,
+					Online:             element.Online,
+					Attributes:         element.Attributes,
+					HasSound:           element.HasSound,
+					Content:            element.Content,
+					Name:               element.Name,
+					URL:                element.URL,
+					Metadata:           hydration.work.Metadata,
+				}
 			}
+			return frozenLayout, nil
 		}
-
-		dataToInject["CurrentWorkLayedOut"] = frozenLayout
 	}
 
+	// Additional data overrides data with the same name
 	for key, value := range g.AdditionalData {
-		dataToInject[key] = value
+		value := value
+		sharedData[key] = func() (interface{}, error) { return value, nil }
+		delete(dataToInject, key)
 	}
 
+	file := GeneratedJSFile{Language: hydration.language, Prelude: prelude}
+	// Sort names so that the same data always generates the same file (see BuildManifest)
+	referenced := g.referencedGlobalsOf(templateName, compiledPugTemplate)
+	names := make([]string, 0)
+	for name := range referenced {
+		if dataToInject[name] != nil || sharedData[name] != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
 	dataDeclarations := make([]string, 0)
-	for name, value := range dataToInject {
-		// Don't use JSON tags, use the Go struct field names
-		jsoned, err := jsoniter.Config{TagKey: "notjson"}.Froze().MarshalToString(value)
-		if err != nil {
-			return "", fmt.Errorf("while converting %s JSON: %w", name, err)
+	for _, name := range names {
+		if compute, ok := dataToInject[name]; ok {
+			value, err := compute()
+			if err != nil {
+				return GeneratedJSFile{}, err
+			}
+			jsoned, err := marshalTemplateData(value)
+			if err != nil {
+				return GeneratedJSFile{}, fmt.Errorf("while converting %s JSON: %w", name, err)
+			}
+			dataDeclarations = append(dataDeclarations, fmt.Sprintf("globalThis.%s = %s;", name, jsoned))
+		} else {
+			data, err := g.sharedDataOf(hydration.language, name, sharedData[name])
+			if err != nil {
+				return GeneratedJSFile{}, err
+			}
+			file.SharedData = append(file.SharedData, data)
+			// Each page gets its own view, which it can change without affecting other pages
+			dataDeclarations = append(dataDeclarations, fmt.Sprintf("globalThis.%s = __ortfomk_view(__ortfomk_shared[%q]);", name, name))
 		}
-		dataDeclarations = append(dataDeclarations, fmt.Sprintf("const %s = %s;", name, jsoned))
 	}
-	templateCall := "template({ " + strings.Join(keys(dataToInject), ", ") + " });"
+	file.Globals = names
+	templateCall := "template({ " + strings.Join(names, ", ") + " });"
+
+	file.appendSection(SectionData, "/*data*/\n"+strings.Join(dataDeclarations, "\n")+"\n")
+	file.appendSection(SectionCompiledPugTemplate, "/*compiled pug template*/\n"+compiledPugTemplate+"\n")
+	file.appendSection(SectionTemplateCall, "/*template call*/\n"+templateCall)
+	return file, nil
+}
 
-	return "/*prelude*/" + prelude + "\n/*data*/\n" + strings.Join(dataDeclarations, "\n") + "\n/*static template functions*/\n" + staticTemplateFunctions + "\n/*compiled pug template*/\n" + compiledPugTemplate + "\n/*template call*/\n" + templateCall, nil
+// marshalTemplateData converts data injected into templates to JSON.
+// JSON tags are not used: templates use the Go struct field names.
+func marshalTemplateData(value interface{}) (string, error) {
+	return jsoniter.Config{TagKey: "notjson", SortMapKeys: true}.Froze().MarshalToString(value)
 }
 
 func (w WorkOneLang) ColorsCSS() string {
@@ -261,34 +316,42 @@ func (w WorkOneLang) Freeze() workOneLangFrozen {
 	}
 }
 
+// Summary returns the plain text of the work's first paragraph.
+// It is empty if that paragraph can't be converted to plain text, which is reported when loading the works (see LoadWorks).
 func (w WorkOneLang) Summary() string {
+	summary, _ := w.summary()
+	return summary
+}
+
+func (w WorkOneLang) summary() (string, error) {
 	if len(w.Paragraphs) == 0 {
-		return ""
+		return "", nil
 	}
 
-	summary := w.Paragraphs[0].Content
-	html, err := goquery.NewDocumentFromReader(strings.NewReader(summary))
+	summary, err := paragraphToText(w.Paragraphs[0].Content)
 	if err != nil {
-		LogError("while creating plain text of first paragraph: %s", err)
-		return ""
+		return "", fmt.Errorf("while creating plain text of first paragraph: %w", err)
+	}
+	return summary, nil
+}
+
+// paragraphToText converts the HTML content of a paragraph to plain text, with footnote references as superscript numbers.
+func paragraphToText(content string) (string, error) {
+	html, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return "", err
 	}
 	html.Find("sup.footnote-ref").Each(func(i int, s *goquery.Selection) {
 		s.ReplaceWithHtml(superscriptize(s.Find("a").First().Text()))
 	})
-	summary, err = html.Html()
+	content, err = html.Html()
 	if err != nil {
-		LogError("while creating plain text of first paragraph: %s", err)
-		return ""
+		return "", err
 	}
-	summary, err = html2text.FromString(summary, html2text.Options{
+	return html2text.FromString(content, html2text.Options{
 		OmitLinks: true,
 		TextOnly:  true,
 	})
-	if err != nil {
-		LogError("while creating plain text of first paragraph: %s", err)
-		return ""
-	}
-	return summary
 }
 
 // superscriptize replaces all number characters in given text with their unicode superscript equivalent.
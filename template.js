@@ -314,3 +314,85 @@ function IsWorkInCollection(work, collection) {
 function CollectionsOfWork(work) {
   return all_collections.filter(c => IsWorkInCollection(work, c))
 }
+
+// searchIndexClient runs in the browser: it loads the search index at indexURL (see search.go) and returns a search function.
+// search(query) resolves to the works matching every word of the query, best matches first,
+// with their URL resolved against the index's URL. The last word of the query also matches as a prefix, for search-as-you-type.
+function searchIndexClient(indexURL) {
+  let loading = null
+  const load = () =>
+    (loading ??= fetch(indexURL).then(response => response.json()))
+  const stem = (stemmer, word) => {
+    for (const step of stemmer.steps) {
+      const rule = step.find(
+        ([suffix, replacement]) =>
+          word.endsWith(suffix) &&
+          [...word].length - [...suffix].length + [...replacement].length >=
+            stemmer.minimumStemLength
+      )
+      if (rule) {
+        word = word.slice(0, word.length - rule[0].length) + rule[1]
+      }
+    }
+    return word
+  }
+  const terms = (index, text) =>
+    (
+      text
+        .toLowerCase()
+        .normalize("NFD")
+        .replace(/\p{Mn}/gu, "")
+        .match(/[\p{L}\p{N}]+/gu) || []
+    )
+      .filter(word => [...word].length >= 2 && !index.stopWords.includes(word))
+      .map(word => stem(index.stemmer, word))
+
+  return async function search(query) {
+    const index = await load()
+    const queryTerms = terms(index, query)
+    if (!queryTerms.length) {
+      return []
+    }
+    let scores = null
+    queryTerms.forEach((term, i) => {
+      const matchingTerms =
+        i === queryTerms.length - 1
+          ? Object.keys(index.terms).filter(t => t.startsWith(term))
+          : [term].filter(t => t in index.terms)
+      const termScores = new Map()
+      for (const matchingTerm of matchingTerms) {
+        for (const [document, weight] of index.terms[matchingTerm]) {
+          termScores.set(document, (termScores.get(document) || 0) + weight)
+        }
+      }
+      scores =
+        scores === null
+          ? termScores
+          : new Map(
+              [...scores]
+                .filter(([document]) => termScores.has(document))
+                .map(([document, score]) => [
+                  document,
+                  score + termScores.get(document),
+                ])
+            )
+    })
+    return [...scores]
+      .sort(([, a], [, b]) => b - a)
+      .map(([document, score]) => ({
+        ...index.documents[document],
+        url: index.documents[document].url
+          ? new URL(index.documents[document].url, new URL(indexURL, location.href)).href
+          : "",
+        score,
+      }))
+  }
+}
+
+// SearchScript returns JavaScript code that defines a `search` function in the browser, querying the current language's search index.
+// Use it in a template with `script!= SearchScript()`, then call `await search("query")` client-side.
+function SearchScript(functionName = "search") {
+  return `const ${functionName} = (${searchIndexClient.toString()})(${JSON.stringify(
+    search_index_url
+  )});`
+}
@@ -0,0 +1,114 @@
+package ortfomk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// compiledTemplatesCacheVersion is part of every cache key.
+// Change it whenever the way templates are compiled changes, to invalidate existing caches.
+const compiledTemplatesCacheVersion = "1"
+
+// CompileTemplate compiles a pug template (see compileTemplate).
+// Compiled templates are cached on disk, in the configured cache directory: the compiled version is re-used
+// as long as neither the template nor any of the files it (transitively) includes or extends changed.
+func (g *Builder) CompileTemplate(templateName string, templateContent []byte) ([]byte, error) {
+	defer g.recordCompileTiming(templateName, time.Now())
+	if g.Flags.NoCache || g.Configuration.CacheDirectory == "" {
+		return g.compileTemplate(templateName, templateContent)
+	}
+
+	key, err := g.TemplateCacheKey(templateName, templateContent)
+	if err != nil {
+		g.LogWarning("not using the cache for %s: %s", templateName, err)
+		return g.compileTemplate(templateName, templateContent)
+	}
+
+	cachePath := filepath.Join(g.Configuration.CacheDirectory, "templates", key+".js")
+	if cached, err := os.ReadFile(cachePath); err == nil {
+		g.LogDebug("using cached compiled template %s for %s", cachePath, templateName)
+		return cached, nil
+	}
+
+	compiled, err := g.compileTemplate(templateName, templateContent)
+	if err != nil {
+		return compiled, err
+	}
+	if err := writeFileAtomically(cachePath, compiled, 0o644); err != nil {
+		g.LogWarning("couldn't cache the compiled template %s: %s", templateName, err)
+	}
+	return compiled, nil
+}
+
+// TemplateCacheKey hashes everything the compiled version of a template depends on:
+// its path and content, the contents of the files it transitively includes or extends, and the pug compiler used.
+func (g *Builder) TemplateCacheKey(templateName string, templateContent []byte) (string, error) {
+	hasher := sha256.New()
+	fmt.Fprintf(hasher, "ortfomk compiled template v%s\x00%s\x00", compiledTemplatesCacheVersion, g.Configuration.PugCompiler)
+	if g.Configuration.PugCompiler == PugCompilerEmbedded {
+		fmt.Fprintf(hasher, "%x\x00", sha256.Sum256([]byte(pugCompilerBundle)))
+	}
+	writeHashedFile(hasher, templateName, templateContent)
+
+	dependencies, err := g.TransitiveDependencies(templateName, templateContent)
+	if err != nil {
+		return "", err
+	}
+	for _, dependency := range dependencies {
+		content, err := os.ReadFile(dependency)
+		if err != nil {
+			return "", fmt.Errorf("while reading dependency %s: %w", dependency, err)
+		}
+		writeHashedFile(hasher, dependency, content)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func writeHashedFile(hasher hash.Hash, path string, content []byte) {
+	fmt.Fprintf(hasher, "%s\x00%d\x00", path, len(content))
+	hasher.Write(content)
+}
+
+// TransitiveDependencies returns the sorted paths of all files included or extended by the given template,
+// and by the files they include or extend, and so on.
+func (g *Builder) TransitiveDependencies(templateName string, templateContent []byte) ([]string, error) {
+	seen := map[string]bool{templateName: true}
+	toVisit := []struct {
+		path    string
+		content []byte
+	}{{templateName, templateContent}}
+
+	for len(toVisit) > 0 {
+		current := toVisit[0]
+		toVisit = toVisit[1:]
+		for _, dependency := range Dependencies(string(current.content)) {
+			resolved, err := resolvePugInclude(dependency, current.path, g.TemplatesDirectory)
+			if err != nil {
+				return []string{}, err
+			}
+			if seen[resolved] {
+				continue
+			}
+			seen[resolved] = true
+			content, err := os.ReadFile(resolved)
+			if err != nil {
+				return []string{}, fmt.Errorf("while reading %s, included by %s: %w", resolved, current.path, err)
+			}
+			toVisit = append(toVisit, struct {
+				path    string
+				content []byte
+			}{resolved, content})
+		}
+	}
+
+	delete(seen, templateName)
+	dependencies := keys(seen)
+	sort.Strings(dependencies)
+	return dependencies, nil
+}
@@ -0,0 +1,54 @@
+package ortfomk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateCacheKey(t *testing.T) {
+	defer SetGlobalData(g)
+	templates := t.TempDir()
+	SetGlobalData(&GlobalData{TemplatesDirectory: templates, Configuration: DefaultConfiguration()})
+
+	page := filepath.Join(templates, "works", ":work.pug")
+	os.MkdirAll(filepath.Join(templates, "works"), 0755)
+	os.MkdirAll(filepath.Join(templates, "mixins"), 0755)
+	os.WriteFile(filepath.Join(templates, "layout.pug"), []byte("include /mixins/card\nblock content"), 0644)
+	os.WriteFile(filepath.Join(templates, "mixins", "card.pug"), []byte("mixin card\n  p card"), 0644)
+	pageContent := []byte("extends ../layout\nblock content\n  +card")
+
+	dependencies, err := TransitiveDependencies(page, pageContent)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(templates, "layout.pug"), filepath.Join(templates, "mixins", "card.pug")}, dependencies)
+
+	before, err := TemplateCacheKey(page, pageContent)
+	assert.NoError(t, err)
+	unchanged, _ := TemplateCacheKey(page, pageContent)
+	assert.Equal(t, before, unchanged)
+
+	os.WriteFile(filepath.Join(templates, "mixins", "card.pug"), []byte("mixin card\n  p changed"), 0644)
+	after, err := TemplateCacheKey(page, pageContent)
+	assert.NoError(t, err)
+	assert.NotEqual(t, before, after)
+}
+
+func TestCompileTemplateUsesCache(t *testing.T) {
+	defer SetGlobalData(g)
+	templates := t.TempDir()
+	config := DefaultConfiguration()
+	config.CacheDirectory = filepath.Join(t.TempDir(), "cache")
+	SetGlobalData(&GlobalData{TemplatesDirectory: templates, Configuration: config})
+
+	page := filepath.Join(templates, "index.pug")
+	key, err := TemplateCacheKey(page, []byte("p hi"))
+	assert.NoError(t, err)
+	os.MkdirAll(filepath.Join(config.CacheDirectory, "templates"), 0755)
+	os.WriteFile(filepath.Join(config.CacheDirectory, "templates", key+".js"), []byte("function template() {}"), 0644)
+
+	compiled, err := CompileTemplate(page, []byte("p hi"))
+	assert.NoError(t, err)
+	assert.Equal(t, "function template() {}", string(compiled))
+}
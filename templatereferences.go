@@ -0,0 +1,94 @@
+package ortfomk
+
+import (
+	"regexp"
+	"sync"
+)
+
+var javascriptIdentifierPattern = regexp.MustCompile(`[A-Za-z_$][A-Za-z0-9_$]*`)
+
+// staticTemplateFunctionPattern matches the start of top-level declarations in template.js.
+var staticTemplateFunctionPattern = regexp.MustCompile(`(?m)^(?:async\s+)?(?:function\s*\*?\s*|const\s+|let\s+|var\s+)([A-Za-z_$][A-Za-z0-9_$]*)`)
+
+// identifiersIn returns every identifier-looking word of the given JavaScript code.
+// Words inside strings and comments are included too: the result is a superset of what the code really references.
+func identifiersIn(code string) map[string]bool {
+	identifiers := make(map[string]bool)
+	for _, identifier := range javascriptIdentifierPattern.FindAllString(code, -1) {
+		identifiers[identifier] = true
+	}
+	return identifiers
+}
+
+// staticTemplateFunctionsReferences maps each top-level declaration of template.js to the identifiers its code references.
+var staticTemplateFunctionsReferences = func() map[string]map[string]bool {
+	references := make(map[string]map[string]bool)
+	declarations := staticTemplateFunctionPattern.FindAllStringSubmatchIndex(staticTemplateFunctions, -1)
+	for i, declaration := range declarations {
+		end := len(staticTemplateFunctions)
+		if i < len(declarations)-1 {
+			end = declarations[i+1][0]
+		}
+		name := staticTemplateFunctions[declaration[2]:declaration[3]]
+		references[name] = identifiersIn(staticTemplateFunctions[declaration[0]:end])
+	}
+	return references
+}()
+
+// referencedGlobalsCache holds the globals referenced by the compiled templates of the current build.
+type referencedGlobalsCache struct {
+	mu sync.Mutex
+	// Maps template names to the globals their compiled template references
+	entries map[string]referencedGlobalsEntry
+}
+
+type referencedGlobalsEntry struct {
+	// Hash of the compiled template the globals were found in
+	hash    string
+	globals map[string]bool
+}
+
+// resetReferencedGlobals forgets referenced globals of compiled templates, before starting a new full build.
+func (g *Builder) resetReferencedGlobals() {
+	g.referencedGlobals.mu.Lock()
+	defer g.referencedGlobals.mu.Unlock()
+	g.referencedGlobals.entries = make(map[string]referencedGlobalsEntry)
+}
+
+// referencedGlobalsOf is ReferencedGlobals, computed once per version of the template's compiled code.
+// Only the globals of the latest version of each template are kept.
+func (g *Builder) referencedGlobalsOf(templateName string, compiledPugTemplate string) map[string]bool {
+	hash := hashString(compiledPugTemplate)
+	g.referencedGlobals.mu.Lock()
+	defer g.referencedGlobals.mu.Unlock()
+	if entry, ok := g.referencedGlobals.entries[templateName]; ok && entry.hash == hash {
+		return entry.globals
+	}
+	globals := ReferencedGlobals(compiledPugTemplate)
+	if g.referencedGlobals.entries == nil {
+		g.referencedGlobals.entries = make(map[string]referencedGlobalsEntry)
+	}
+	g.referencedGlobals.entries[templateName] = referencedGlobalsEntry{hash: hash, globals: globals}
+	return globals
+}
+
+// ReferencedGlobals returns the names that the compiled template may reference, either directly,
+// or through the static template functions it calls (see template.js).
+// GenerateJSFile only injects data that is referenced, so that e.g. tag pages don't pay for serializing every work.
+// The analysis is static and conservative: any identifier-looking word counts, even in strings or comments.
+// Data accessed dynamically (e.g. with globalThis["all_" + kind]) is not detected.
+func ReferencedGlobals(compiledPugTemplate string) map[string]bool {
+	globals := identifiersIn(compiledPugTemplate)
+	toVisit := keys(globals)
+	for len(toVisit) > 0 {
+		name := toVisit[len(toVisit)-1]
+		toVisit = toVisit[:len(toVisit)-1]
+		for identifier := range staticTemplateFunctionsReferences[name] {
+			if !globals[identifier] {
+				globals[identifier] = true
+				toVisit = append(toVisit, identifier)
+			}
+		}
+	}
+	return globals
+}
@@ -0,0 +1,59 @@
+package ortfomk
+
+import (
+	"testing"
+
+	ortfodb "github.com/ortfo/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferencedGlobals(t *testing.T) {
+	globals := ReferencedGlobals(`function template(locals) { return CurrentTag.Plural + lookupTag("web").Singular }`)
+	assert.True(t, globals["CurrentTag"])
+	assert.True(t, globals["lookupTag"])
+	assert.True(t, globals["all_tags"], "referenced by lookupTag")
+	assert.True(t, globals["URLName"], "referenced by lookupTag")
+	assert.False(t, globals["all_works"])
+	assert.False(t, globals["_translations"])
+
+	globals = ReferencedGlobals(`function template(locals) { return SearchScript() }`)
+	assert.True(t, globals["search_index_url"])
+}
+
+func TestReferencedGlobalsOfKeepsOnlyTheLatestVersionOfTemplates(t *testing.T) {
+	builder := &Builder{}
+	assert.True(t, builder.referencedGlobalsOf("tag.pug", `function template(locals) { return CurrentTag }`)["CurrentTag"])
+	globals := builder.referencedGlobalsOf("tag.pug", `function template(locals) { return CurrentWork }`)
+	assert.True(t, globals["CurrentWork"])
+	assert.False(t, globals["CurrentTag"])
+	assert.Len(t, builder.referencedGlobals.entries, 1)
+
+	builder.resetReferencedGlobals()
+	assert.Empty(t, builder.referencedGlobals.entries)
+}
+
+func TestGenerateJSFileOnlyInjectsReferencedData(t *testing.T) {
+	defer SetGlobalData(g)
+	SetGlobalData(&GlobalData{AdditionalData: map[string]interface{}{"socials": []string{"mastodon"}, "unused": 1}})
+	g.Tags = []Tag{{Singular: "poster", Plural: "posters"}}
+	g.Works = []Work{{Work: ortfodb.Work{ID: "poster"}}}
+
+	file, err := GenerateJSFile(&Hydration{language: "en", tag: Tag{Singular: "poster", Plural: "posters"}}, "tag.pug", `function template(locals) { return CurrentTag.Plural + socials[0] }`)
+	assert.NoError(t, err)
+	data := ""
+	for _, section := range file.Sections {
+		if section.Name == SectionData {
+			data = file.Content[section.Start:section.End]
+		}
+	}
+	assert.Contains(t, data, "globalThis.CurrentTag = ")
+	assert.Contains(t, data, `globalThis.socials = __ortfomk_view(__ortfomk_shared["socials"]);`)
+	assert.NotContains(t, data, "all_works")
+	assert.NotContains(t, data, "unused")
+	assert.Len(t, file.SharedData, 1)
+	assert.Equal(t, `["mastodon"]`, file.SharedData[0].JSON)
+	assert.Contains(t, file.Content, "template({ CurrentTag, socials });")
+
+	_, err = GenerateJSFile(&Hydration{language: "en", work: Work{Work: ortfodb.Work{ID: "poster"}}}, "work.pug", `function template(locals) { return CurrentWork.ID }`)
+	assert.NoError(t, err, "the layout of the work is not computed when not referenced")
+}
@@ -0,0 +1,272 @@
+package ortfomk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// TimingPhase is a phase of building a page that gets timed.
+type TimingPhase string
+
+const (
+	// Compiling the template to JavaScript (see CompileTemplate). Done once per template, not once per page.
+	PhaseCompile TimingPhase = "compile"
+	// Generating the JavaScript file that renders the page (see GenerateJSFile)
+	PhaseGenerate TimingPhase = "generate"
+	// Running the generated JavaScript file with V8 (see RunJSFile)
+	PhaseRun TimingPhase = "run"
+	// Translating the rendered page (see TranslateHydratedPage)
+	PhaseTranslate TimingPhase = "translate"
+	// Writing the page as a PDF file (see WritePDF)
+	PhasePDF TimingPhase = "pdf"
+)
+
+// TimingPhases lists every phase, in the order they happen in.
+var TimingPhases = []TimingPhase{PhaseCompile, PhaseGenerate, PhaseRun, PhaseTranslate, PhasePDF}
+
+// PhaseDurations maps phases to the time spent in them.
+// They are encoded to JSON as (fractional) milliseconds.
+type PhaseDurations map[TimingPhase]time.Duration
+
+func (d PhaseDurations) MarshalJSON() ([]byte, error) {
+	milliseconds := make(map[TimingPhase]float64, len(d))
+	for phase, duration := range d {
+		milliseconds[phase] = toMilliseconds(duration)
+	}
+	return json.Marshal(milliseconds)
+}
+
+// Total returns the time spent in all phases.
+func (d PhaseDurations) Total() (total time.Duration) {
+	for _, duration := range d {
+		total += duration
+	}
+	return
+}
+
+// PageTiming is the time spent building a page, per phase.
+// It does not include the compilation of its template, which is shared by all pages of the template (see TemplateTiming).
+type PageTiming struct {
+	Template string         `json:"template"`
+	OutPath  string         `json:"output"`
+	Phases   PhaseDurations `json:"phases"`
+}
+
+// TemplateTiming is the time spent on a template: compiling it and building all of its pages.
+type TemplateTiming struct {
+	Template string        `json:"template"`
+	Pages    int           `json:"pages"`
+	Compile  time.Duration `json:"-"`
+	Total    time.Duration `json:"-"`
+}
+
+func (t TemplateTiming) MarshalJSON() ([]byte, error) {
+	type plain TemplateTiming
+	return json.Marshal(struct {
+		plain
+		Compile float64 `json:"compile"`
+		Total   float64 `json:"total"`
+	}{plain(t), toMilliseconds(t.Compile), toMilliseconds(t.Total)})
+}
+
+// TimingReport sums up where the time of a build was spent.
+type TimingReport struct {
+	// Pages, slowest first
+	Pages []PageTiming `json:"pages"`
+	// Templates, slowest first
+	Templates []TemplateTiming `json:"templates"`
+	// Time spent in each phase, across all pages and templates
+	Phases PhaseDurations `json:"phases"`
+}
+
+// timings records the time spent on each template and page during the current build.
+type timings struct {
+	mu        sync.Mutex
+	compiles  map[string]time.Duration
+	pages     map[string]*PageTiming
+	pagesList []*PageTiming
+}
+
+// resetTimings forgets recorded timings, before starting a new full build.
+func (g *Builder) resetTimings() {
+	g.timings.mu.Lock()
+	defer g.timings.mu.Unlock()
+	g.timings.compiles = make(map[string]time.Duration)
+	g.timings.pages = make(map[string]*PageTiming)
+	g.timings.pagesList = nil
+}
+
+// recordCompileTiming records that compiling the given template took the time since startedAt.
+// It's meant to be deferred: defer recordCompileTiming(templateName, time.Now())
+func (g *Builder) recordCompileTiming(templateName string, startedAt time.Time) {
+	g.timings.mu.Lock()
+	defer g.timings.mu.Unlock()
+	if g.timings.compiles == nil {
+		g.timings.compiles = make(map[string]time.Duration)
+	}
+	g.timings.compiles[g.GetPathRelativeToSrcDir(templateName)] += time.Since(startedAt)
+}
+
+// recordPageTiming records that the given phase of building the page at outPath from templateName took the time since startedAt.
+func (g *Builder) recordPageTiming(templateName string, outPath string, phase TimingPhase, startedAt time.Time) {
+	duration := time.Since(startedAt)
+	g.timings.mu.Lock()
+	defer g.timings.mu.Unlock()
+	if g.timings.pages == nil {
+		g.timings.pages = make(map[string]*PageTiming)
+	}
+	page, ok := g.timings.pages[outPath]
+	if !ok {
+		page = &PageTiming{Template: g.GetPathRelativeToSrcDir(templateName), OutPath: outPath, Phases: make(PhaseDurations)}
+		g.timings.pages[outPath] = page
+		g.timings.pagesList = append(g.timings.pagesList, page)
+	}
+	page.Phases[phase] += duration
+}
+
+// CurrentTimingReport returns the timing report of the current (or last) build.
+func (g *Builder) CurrentTimingReport() TimingReport {
+	g.timings.mu.Lock()
+	defer g.timings.mu.Unlock()
+	report := TimingReport{
+		Pages:     make([]PageTiming, 0, len(g.timings.pagesList)),
+		Templates: make([]TemplateTiming, 0),
+		Phases:    make(PhaseDurations),
+	}
+	templates := make(map[string]*TemplateTiming)
+	templateOf := func(name string) *TemplateTiming {
+		if _, ok := templates[name]; !ok {
+			templates[name] = &TemplateTiming{Template: name}
+		}
+		return templates[name]
+	}
+
+	for name, duration := range g.timings.compiles {
+		template := templateOf(name)
+		template.Compile += duration
+		template.Total += duration
+		report.Phases[PhaseCompile] += duration
+	}
+	for _, page := range g.timings.pagesList {
+		phases := make(PhaseDurations, len(page.Phases))
+		for phase, duration := range page.Phases {
+			phases[phase] = duration
+			report.Phases[phase] += duration
+		}
+		report.Pages = append(report.Pages, PageTiming{Template: page.Template, OutPath: page.OutPath, Phases: phases})
+		template := templateOf(page.Template)
+		template.Pages++
+		template.Total += phases.Total()
+	}
+	for _, template := range templates {
+		report.Templates = append(report.Templates, *template)
+	}
+
+	sort.SliceStable(report.Pages, func(i, j int) bool {
+		if report.Pages[i].Phases.Total() == report.Pages[j].Phases.Total() {
+			return report.Pages[i].OutPath < report.Pages[j].OutPath
+		}
+		return report.Pages[i].Phases.Total() > report.Pages[j].Phases.Total()
+	})
+	sort.SliceStable(report.Templates, func(i, j int) bool {
+		if report.Templates[i].Total == report.Templates[j].Total {
+			return report.Templates[i].Template < report.Templates[j].Template
+		}
+		return report.Templates[i].Total > report.Templates[j].Total
+	})
+	return report
+}
+
+// WriteText writes the time spent per phase, and the given number of slowest templates and pages, as tables.
+func (r TimingReport) WriteText(w io.Writer, slowest int) error {
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "PHASE\tTIME")
+	for _, phase := range TimingPhases {
+		fmt.Fprintf(table, "%s\t%s\n", phase, formatDuration(r.Phases[phase]))
+	}
+	fmt.Fprintf(table, "total\t%s\n", formatDuration(r.Phases.Total()))
+	// Empty lines end a table, so that columns of the next one are aligned independently
+	fmt.Fprintln(table)
+
+	fmt.Fprintln(table, "SLOWEST TEMPLATES\tPAGES\tCOMPILE\tTOTAL")
+	for _, template := range r.Templates[:minInt(slowest, len(r.Templates))] {
+		fmt.Fprintf(table, "%s\t%d\t%s\t%s\n", template.Template, template.Pages, formatDuration(template.Compile), formatDuration(template.Total))
+	}
+	fmt.Fprintln(table)
+
+	fmt.Fprint(table, "SLOWEST PAGES")
+	for _, phase := range TimingPhases[1:] {
+		fmt.Fprintf(table, "\t%s", strings.ToUpper(string(phase)))
+	}
+	fmt.Fprintln(table, "\tTOTAL")
+	for _, page := range r.Pages[:minInt(slowest, len(r.Pages))] {
+		fmt.Fprint(table, page.OutPath)
+		for _, phase := range TimingPhases[1:] {
+			fmt.Fprintf(table, "\t%s", formatDuration(page.Phases[phase]))
+		}
+		fmt.Fprintf(table, "\t%s\n", formatDuration(page.Phases.Total()))
+	}
+	return table.Flush()
+}
+
+// WriteJSON writes the whole report as JSON. Durations are in milliseconds.
+func (r TimingReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV writes the whole report as CSV, with one row per template and one row per page.
+// Template rows have an empty output column and only the compile phase, page rows have every phase but compile.
+// Durations are in milliseconds.
+func (r TimingReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"template", "output"}
+	for _, phase := range TimingPhases {
+		header = append(header, string(phase))
+	}
+	writer.Write(append(header, "total"))
+	for _, template := range r.Templates {
+		row := []string{template.Template, "", formatMilliseconds(template.Compile)}
+		for range TimingPhases[1:] {
+			row = append(row, "")
+		}
+		writer.Write(append(row, formatMilliseconds(template.Compile)))
+	}
+	for _, page := range r.Pages {
+		row := []string{page.Template, page.OutPath, ""}
+		for _, phase := range TimingPhases[1:] {
+			row = append(row, formatMilliseconds(page.Phases[phase]))
+		}
+		writer.Write(append(row, formatMilliseconds(page.Phases.Total())))
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func toMilliseconds(duration time.Duration) float64 {
+	return float64(duration.Microseconds()) / 1000
+}
+
+func formatMilliseconds(duration time.Duration) string {
+	return strconv.FormatFloat(toMilliseconds(duration), 'f', 3, 64)
+}
+
+func formatDuration(duration time.Duration) string {
+	return duration.Round(time.Millisecond / 10).String()
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
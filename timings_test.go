@@ -0,0 +1,36 @@
+package ortfomk
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrentTimingReport(t *testing.T) {
+	defer SetGlobalData(g)
+	SetGlobalData(&GlobalData{TemplatesDirectory: "src"})
+	now := time.Now()
+	g.recordCompileTiming("src/:work.pug", now.Add(-10*time.Millisecond))
+	g.recordPageTiming("src/:work.pug", "dist/en/a.html", PhaseRun, now.Add(-5*time.Millisecond))
+	g.recordPageTiming("src/:work.pug", "dist/en/b.html", PhaseRun, now.Add(-50*time.Millisecond))
+	g.recordPageTiming("src/:work.pug", "dist/en/b.html", PhaseTranslate, now.Add(-20*time.Millisecond))
+	g.recordPageTiming("src/index.pug", "dist/en/index.html", PhaseGenerate, now.Add(-1*time.Millisecond))
+
+	report := CurrentTimingReport()
+	assert.Equal(t, []string{"dist/en/b.html", "dist/en/a.html", "dist/en/index.html"}, []string{report.Pages[0].OutPath, report.Pages[1].OutPath, report.Pages[2].OutPath})
+	assert.Equal(t, ":work.pug", report.Templates[0].Template)
+	assert.Equal(t, 2, report.Templates[0].Pages)
+	assert.GreaterOrEqual(t, report.Templates[0].Total, 85*time.Millisecond)
+	assert.GreaterOrEqual(t, report.Phases[PhaseCompile], 10*time.Millisecond)
+	assert.GreaterOrEqual(t, report.Phases[PhaseRun], 55*time.Millisecond)
+
+	output := bytes.Buffer{}
+	assert.NoError(t, report.WriteCSV(&output))
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Equal(t, "template,output,compile,generate,run,translate,pdf,total", lines[0])
+	assert.Len(t, lines, 1+2+3)
+	assert.True(t, strings.HasPrefix(lines[3], ":work.pug,dist/en/b.html,,0.000,"), lines[3])
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -16,8 +17,6 @@ import (
 	"golang.org/x/net/html"
 )
 
-const SourceLanguage = "en"
-
 // This is the ugliest delimiter pair I could come up with. The idea is to prevent conflicts with any potential input.
 const (
 	TranslationStringDelimiterOpen  = "[=[=[={{{"
@@ -26,14 +25,30 @@ const (
 
 // TranslationsOneLang holds both the gettext catalog from the .mo file
 // and a po file object used to update the .po file (e.g. when discovering new translatable strings)
+// Pages are translated concurrently: seenMessages is a thread-safe set, and missingMessages is guarded by mu.
 type TranslationsOneLang struct {
 	poFile          po.File
 	seenMessages    mapset.Set
+	mu              sync.Mutex
 	missingMessages []po.Message
 	language        string
+	// Source language of the site the catalog was loaded for (see LoadTranslations): pages in that language are not translated.
+	sourceLanguage string
+}
+
+// FingerprintOf hashes the translations of the messages in usage, so that pages get re-built when one of the messages they use changes.
+func (t *TranslationsOneLang) FingerprintOf(usage TranslationUsage) string {
+	if t == nil {
+		return ""
+	}
+	messages := strings.Builder{}
+	for _, message := range usage.Seen {
+		messages.WriteString(message.Context + "\x00" + message.ID + "\x00" + t.lookup(message.ID, message.Context) + "\x00")
+	}
+	return hashString(messages.String())
 }
 
-func (t TranslationsOneLang) WriteUnusedMessages() error {
+func (t *TranslationsOneLang) WriteUnusedMessages() error {
 	to := fmt.Sprintf("i18n/%s-unused-messages.yaml", t.language)
 	ioutil.WriteFile(to, []byte("# Generated at "+time.Now().String()+"\n"), 0644)
 	file, err := os.OpenFile(to, os.O_APPEND|os.O_WRONLY, 0644)
@@ -56,28 +71,32 @@ func (t TranslationsOneLang) WriteUnusedMessages() error {
 	return nil
 }
 
-// TranslateToLanguage translates the given html node to french or english, removing translation-related attributes
-func Translate(language string, root *html.Node) string {
+// Translate translates the given html node to the given language, removing translation-related attributes
+func (g *Builder) Translate(language string, root *html.Node) string {
+	return g.Translations[language].translate(root, g.Configuration.SourceLanguage, &TranslationUsage{})
+}
+
+// translate translates the given html node with the catalog, removing translation-related attributes.
+// Nothing is translated when the catalog's language is sourceLanguage.
+func (t *TranslationsOneLang) translate(root *html.Node, sourceLanguage string, usage *TranslationUsage) string {
 	// Open files
 	doc := goquery.NewDocumentFromNode(root)
 	doc.Find("i18n, [i18n]").Each(func(_ int, element *goquery.Selection) {
 		element.RemoveAttr("i18n")
 		msgContext, _ := element.Attr("i18n-context")
 		element.RemoveAttr("i18n-context")
-		if language != SourceLanguage {
+		if t.language != sourceLanguage {
 			innerHTML, _ := element.Html()
 			innerHTML = html.UnescapeString(innerHTML)
 			innerHTML = strings.TrimSpace(innerHTML)
 			if innerHTML == "" {
 				return
 			}
-			translated, err := g.Translations[language].GetTranslation(innerHTML, msgContext)
+			usage.Seen = append(usage.Seen, TranslationMessageRef{ID: innerHTML, Context: msgContext})
+			translated, err := t.GetTranslation(innerHTML, msgContext)
 			if err != nil {
-				LogDebug("adding missing message %q", innerHTML)
-				g.Translations[language].missingMessages = append(g.Translations[language].missingMessages, po.Message{
-					MsgId:      innerHTML,
-					MsgContext: msgContext,
-				})
+				t.addMissingMessage(innerHTML, msgContext)
+				usage.Missing = append(usage.Missing, TranslationMessageRef{ID: innerHTML, Context: msgContext})
 			} else {
 				element.SetHtml(translated)
 			}
@@ -89,6 +108,55 @@ func Translate(language string, root *html.Node) string {
 	return htmlString
 }
 
+// TranslationUsage lists the messages that were looked up while translating a page.
+// It is stored in the build manifest, so that pages that are not re-built still count as using their messages
+// (see TranslationsOneLang.Replay).
+type TranslationUsage struct {
+	Seen []TranslationMessageRef `json:"seen,omitempty"`
+	// Missing messages are the ones that were not found in the catalog
+	Missing []TranslationMessageRef `json:"missing,omitempty"`
+}
+
+// TranslationMessageRef identifies a message of a translation catalog.
+type TranslationMessageRef struct {
+	ID      string `json:"msgid"`
+	Context string `json:"msgctxt,omitempty"`
+}
+
+func (t *TranslationsOneLang) addMissingMessage(msgid string, msgctxt string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.missingMessages = append(t.missingMessages, po.Message{
+		MsgId:      msgid,
+		MsgContext: msgctxt,
+	})
+}
+
+// MissingMessages returns the messages that were not found in the catalog, without duplicates.
+func (t *TranslationsOneLang) MissingMessages() []TranslationMessageRef {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	missing := make([]TranslationMessageRef, 0)
+	for _, message := range t.missingMessages {
+		ref := TranslationMessageRef{ID: message.MsgId, Context: message.MsgContext}
+		if !contains(missing, ref) {
+			missing = append(missing, ref)
+		}
+	}
+	return missing
+}
+
+// Replay marks messages of usage as seen, and adds its missing messages to the catalog,
+// as if the page it was recorded from was translated again.
+func (t *TranslationsOneLang) Replay(usage TranslationUsage) {
+	for _, message := range usage.Seen {
+		t.seenMessages.Add(message.ID + message.Context)
+	}
+	for _, message := range usage.Missing {
+		t.addMissingMessage(message.ID, message.Context)
+	}
+}
+
 type translationString struct {
 	Value   string
 	Args    []interface{}
@@ -106,33 +174,45 @@ type translationString struct {
 //	you have 8 amis
 //
 // TODO: use ICU message syntax instead.
-func (t TranslationsOneLang) TranslateTranslationStrings(content string) string {
+// Content with a translation string that is not valid JSON is returned as is.
+func (t *TranslationsOneLang) TranslateTranslationStrings(content string) string {
+	translated, err := t.translateTranslationStrings(content, &TranslationUsage{})
+	if err != nil {
+		return content
+	}
+	return translated
+}
+
+func (t *TranslationsOneLang) translateTranslationStrings(content string, usage *TranslationUsage) (string, error) {
 	startsAt := strings.Index(content, TranslationStringDelimiterOpen)
 	if startsAt < 0 {
-		return content
+		return content, nil
 	}
 	endsAt := strings.Index(content, TranslationStringDelimiterClose)
 	if endsAt < 0 {
-		return content
+		return content, nil
 	}
 	innerJSON := html.UnescapeString(content[startsAt+len(TranslationStringDelimiterOpen) : endsAt])
 	translation := translationString{}
 	err := json.Unmarshal([]byte(innerJSON), &translation)
 	if err != nil {
-		LogFatal("couldn't parse JSON translation string %q: %s", innerJSON, err)
-		panic(err)
+		return "", fmt.Errorf("couldn't parse JSON translation string %q: %w", innerJSON, err)
 	}
 
-	LogDebug("translating dynamic message %s", innerJSON)
-	return content[:startsAt] + fmt.Sprintf(t.GetTranslationOrMsgid(translation.Value, translation.Context), translation.Args...) + t.TranslateTranslationStrings(content[endsAt+len(TranslationStringDelimiterClose):])
+	usage.Seen = append(usage.Seen, TranslationMessageRef{ID: translation.Value, Context: translation.Context})
+	rest, err := t.translateTranslationStrings(content[endsAt+len(TranslationStringDelimiterClose):], usage)
+	if err != nil {
+		return "", err
+	}
+	return content[:startsAt] + fmt.Sprintf(t.GetTranslationOrMsgid(translation.Value, translation.Context), translation.Args...) + rest, nil
 }
 
-// LoadTranslations reads from i18n/fr.po to load translations
-func LoadTranslations() (Translations, error) {
+// LoadTranslations reads from i18n/<language>.po to load translations, for every language of the site.
+func (g *Builder) LoadTranslations() (Translations, error) {
 	translations := make(Translations)
-	for _, languageCode := range []string{"fr", "en"} {
+	for _, languageCode := range g.Configuration.Languages {
 		translationsFilepath := fmt.Sprintf("i18n/%s.po", languageCode)
-		Status(StepLoadTranslations, ProgressDetails{
+		g.Status(StepLoadTranslations, ProgressDetails{
 			File: translationsFilepath,
 		})
 		poFile, err := po.LoadFile(translationsFilepath)
@@ -146,6 +226,7 @@ func LoadTranslations() (Translations, error) {
 			seenMessages:    mapset.NewSet(),
 			missingMessages: make([]po.Message, 0),
 			language:        languageCode,
+			sourceLanguage:  g.Configuration.SourceLanguage,
 		}
 	}
 	return translations, nil
@@ -153,23 +234,27 @@ func LoadTranslations() (Translations, error) {
 
 // SavePO writes the .po file to the disk, with its potential modifications
 // It removes duplicate messages beforehand
-func (t TranslationsOneLang) SavePO() {
+func (t *TranslationsOneLang) SavePO() {
+	// Work on a copy, so that the catalog used to translate pages is left as it is
+	poFile := t.poFile
 	// TODO: sort file after saving, (po.File).Save is not stable... (creates unecessary diffs in git)
 	// Remove unused messages with empty msgstrs
 	uselessRemoved := make([]po.Message, 0)
-	for _, msg := range t.poFile.Messages {
+	for _, msg := range poFile.Messages {
 		if !t.seenMessages.Contains(msg.MsgId+msg.MsgContext) && msg.MsgStr == "" {
 			t.seenMessages.Remove(msg.MsgId + msg.MsgContext)
 			continue
 		}
 		uselessRemoved = append(uselessRemoved, msg)
 	}
-	t.poFile.Messages = uselessRemoved
+	poFile.Messages = uselessRemoved
 	// Add missing messages
-	t.poFile.Messages = append(t.poFile.Messages, t.missingMessages...)
+	t.mu.Lock()
+	poFile.Messages = append(poFile.Messages, t.missingMessages...)
+	t.mu.Unlock()
 	// Remove duplicate messages
 	dedupedMessages := make([]po.Message, 0)
-	for _, msg := range t.poFile.Messages {
+	for _, msg := range poFile.Messages {
 		var isDupe bool
 		for _, msg2 := range dedupedMessages {
 			if msg.MsgId == msg2.MsgId && msg.MsgContext == msg2.MsgContext {
@@ -180,10 +265,10 @@ func (t TranslationsOneLang) SavePO() {
 			dedupedMessages = append(dedupedMessages, msg)
 		}
 	}
-	t.poFile.Messages = dedupedMessages
+	poFile.Messages = dedupedMessages
 	// Sort them to guarantee a stable write
-	sort.Sort(ByMsgIdAndCtx(t.poFile.Messages))
-	t.poFile.Save(fmt.Sprintf("i18n/%s.po", t.language))
+	sort.Sort(ByMsgIdAndCtx(poFile.Messages))
+	poFile.Save(fmt.Sprintf("i18n/%s.po", t.language))
 }
 
 // ByMsgIdAndCtx implement sorting gettext messages by their msgid+msgctxt
@@ -203,18 +288,26 @@ func (b ByMsgIdAndCtx) Swap(i, j int) {
 
 // GetTranslation returns the msgstr corresponding to msgid and msgctxt from the .po file
 // If not found, it returns an error
-func (t TranslationsOneLang) GetTranslation(msgid string, msgctxt string) (string, error) {
+func (t *TranslationsOneLang) GetTranslation(msgid string, msgctxt string) (string, error) {
 	t.seenMessages.Add(msgid + msgctxt)
+	if translated := t.lookup(msgid, msgctxt); translated != "" {
+		return translated, nil
+	}
+	return "", errors.New(fmt.Sprintf("cannot find msgstr in %s with msgid=%q and msgctx=%q", t.language, msgid, msgctxt))
+}
+
+// lookup returns the translation of msgid in the msgctxt context, or "" if there is none.
+func (t *TranslationsOneLang) lookup(msgid string, msgctxt string) string {
 	for _, message := range t.poFile.Messages {
 		if message.MsgId == msgid && message.MsgStr != "" && message.MsgContext == msgctxt {
-			return message.MsgStr, nil
+			return message.MsgStr
 		}
 	}
-	return "", errors.New(fmt.Sprintf("cannot find msgstr in %s with msgid=%q and msgctx=%q", t.language, msgid, msgctxt))
+	return ""
 }
 
 // GetTranslationOrMsgid is like GetTranslation but it returns the given msgid verbatim instead of returning an error
-func (t TranslationsOneLang) GetTranslationOrMsgid(msgid string, msgctx string) string {
+func (t *TranslationsOneLang) GetTranslationOrMsgid(msgid string, msgctx string) string {
 	translated, err := t.GetTranslation(msgid, msgctx)
 	if err != nil {
 		return msgid
@@ -1,10 +1,13 @@
 package ortfomk
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mattn/go-isatty"
@@ -31,7 +34,7 @@ func (d DummySpinner) Message(string) {}
 func (d DummySpinner) Pause() error   { return nil }
 func (d DummySpinner) Unpause() error { return nil }
 
-func CreateSpinner() Spinner {
+func (g *Builder) CreateSpinner() Spinner {
 	writer := os.Stdout
 
 	// Don't clog stdout if we're not in a tty
@@ -55,10 +58,11 @@ func CreateSpinner() Spinner {
 	})
 
 	if err != nil {
-		LogError("Couldn't start spinner: %s", err)
+		g.LogError("Couldn't start spinner: %s", err)
 		return DummySpinner{}
 	}
-	if g.Flags.Silent {
+	// Progress events written to stdout would be mixed with the spinner
+	if g.Flags.Silent || logFormat == LogFormatJSON || g.Flags.ProgressEvents == "-" {
 		return DummySpinner{}
 	}
 
@@ -75,7 +79,14 @@ func absorb[T any](value T, err error) (val T) {
 	return
 }
 
-func UpdateSpinner() {
+// UpdateSpinner shows the current progress in the spinner.
+func (g *Builder) UpdateSpinner() {
+	g.updateSpinner(g.ProgressFileData())
+}
+
+// updateSpinner shows the given progress in the spinner.
+// yacspin has its own mutex, so this can be called from multiple goroutines at once.
+func (g *Builder) updateSpinner(progress ProgressFile) {
 	var message string
 	cwdRel := func(p string) string {
 		if pretty, err := filepath.Rel(absorb(os.Getwd()), p); err == nil {
@@ -84,91 +95,192 @@ func UpdateSpinner() {
 			return p
 		}
 	}
-	switch g.Progress.Step {
+	switch progress.Current.Step {
 	case StepBuildPage:
-		message = fmt.Sprintf("Building page [magenta]%s[reset] as [magenta]%s[reset]", cwdRel(g.Progress.File), cwdRel(g.CurrentOutputFile))
+		message = fmt.Sprintf("Building page [magenta]%s[reset] as [magenta]%s[reset]", cwdRel(progress.Current.File), cwdRel(progress.Current.Output))
 	case StepDeadLinks:
 		message = "Checking for dead links (this might take a while, disable it with DEADLINKS_CHECK=0)"
 	case StepGeneratePDF:
-		message = fmt.Sprintf("Generating PDF for [magenta]%s[reset] as [magenta]%s[reset]", cwdRel(g.Progress.File), cwdRel(g.CurrentOutputFile))
+		message = fmt.Sprintf("Generating PDF for [magenta]%s[reset] as [magenta]%s[reset]", cwdRel(progress.Current.File), cwdRel(progress.Current.Output))
 	case StepLoadExternalSites:
-		message = fmt.Sprintf("Loading external sites from [magenta]%s[reset]", cwdRel(g.Progress.File))
+		message = fmt.Sprintf("Loading external sites from [magenta]%s[reset]", cwdRel(progress.Current.File))
 	case StepLoadTags:
-		message = fmt.Sprintf("Loading tags from [magenta]%s[reset]", cwdRel(g.Progress.File))
+		message = fmt.Sprintf("Loading tags from [magenta]%s[reset]", cwdRel(progress.Current.File))
 	case StepLoadTechnologies:
-		message = fmt.Sprintf("Loading technologies from [magenta]%s[reset]", cwdRel(g.Progress.File))
+		message = fmt.Sprintf("Loading technologies from [magenta]%s[reset]", cwdRel(progress.Current.File))
 	case StepLoadTranslations:
-		message = fmt.Sprintf("Loading translations from [magenta]%s[reset]", cwdRel(g.Progress.File))
+		message = fmt.Sprintf("Loading translations from [magenta]%s[reset]", cwdRel(progress.Current.File))
 	case StepLoadWorks:
-		message = fmt.Sprintf("Loading works from database [magenta]%s[reset]", cwdRel(g.Progress.File))
+		message = fmt.Sprintf("Loading works from database [magenta]%s[reset]", cwdRel(progress.Current.File))
 	case StepLoadCollections:
-		message = fmt.Sprintf("Loading work collections from [magenta]%s[reset]", cwdRel(g.Progress.File))
+		message = fmt.Sprintf("Loading work collections from [magenta]%s[reset]", cwdRel(progress.Current.File))
 	default:
-		message = string(g.Progress.Step)
+		message = string(progress.Current.Step)
 	}
 	var currentObjectType = ""
-	if strings.Contains(g.Progress.File, ":work") {
+	if strings.Contains(progress.Current.File, ":work") {
 		currentObjectType = "work"
-	} else if strings.Contains(g.Progress.File, ":tag") {
+	} else if strings.Contains(progress.Current.File, ":tag") {
 		currentObjectType = "tag"
-	} else if strings.Contains(g.Progress.File, ":tech") {
+	} else if strings.Contains(progress.Current.File, ":tech") {
 		currentObjectType = "tech"
-	} else if strings.Contains(g.Progress.File, ":site") {
+	} else if strings.Contains(progress.Current.File, ":site") {
 		currentObjectType = "site"
-	} else if strings.Contains(g.Progress.File, ":collection") {
+	} else if strings.Contains(progress.Current.File, ":collection") {
 		currentObjectType = "collection"
-	} else if g.Progress.Step == StepBuildPage || g.Progress.Step == StepGeneratePDF {
+	} else if progress.Current.Step == StepBuildPage || progress.Current.Step == StepGeneratePDF {
 		currentObjectType = "page"
 	}
 
 	fullMessage := colorstring.Color(fmt.Sprintf(
 		"[light_blue]%3d%%[reset] [bold][green]%s[reset] [bold]%s [yellow]%s[reset][bold][dim]:[reset] %s…",
-		g.ProgressPercent(),
+		progress.Percent,
 		currentObjectType,
-		g.CurrentObjectID,
-		g.CurrentLanguage,
+		progress.Current.ID,
+		progress.Current.Language,
 		message,
 	))
 	g.Spinner.Message(fullMessage)
 }
 
+// Formats of log lines, see SetLogFormat.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var logFormat = LogFormatText
+
+// logOutput is where log lines are written.
+var logOutput io.Writer = os.Stderr
+var logOutputMu sync.Mutex
+
+// SetLogFormat sets the format of log lines: LogFormatText for colored, human-readable lines,
+// or LogFormatJSON for one JSON object per line (see LogLine). The spinner is disabled in JSON mode.
+func SetLogFormat(format string) error {
+	if format != LogFormatText && format != LogFormatJSON {
+		return fmt.Errorf("unknown log format %q, use %q or %q", format, LogFormatText, LogFormatJSON)
+	}
+	logFormat = format
+	return nil
+}
+
+// LogLine is a log line, as written when the log format is LogFormatJSON.
+type LogLine struct {
+	Level     string    `json:"level"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+	// ID of the object (work, tag, etc.) being built
+	ObjectID string `json:"object,omitempty"`
+	Language string `json:"language,omitempty"`
+	// Template (or other file) being processed
+	Template string `json:"template,omitempty"`
+	Output   string `json:"output,omitempty"`
+}
+
+// logJSON writes a log line about page, or about the current step if page is nil.
+// Messages must not be logged while g.mu is held.
+func (g *Builder) logJSON(level string, message string, page *ProgressDetails) {
+	line := LogLine{Level: level, Message: message, Timestamp: time.Now()}
+	if page == nil && g != nil {
+		progress := g.ProgressFileData()
+		page = &ProgressDetails{ObjectID: progress.Current.ID, Language: progress.Current.Language, File: progress.Current.File, OutFile: progress.Current.Output}
+	}
+	if page != nil {
+		line.ObjectID = page.ObjectID
+		line.Language = page.Language
+		line.Template = page.File
+		line.Output = page.OutFile
+	}
+	encoded, err := json.Marshal(line)
+	if err != nil {
+		encoded = []byte(fmt.Sprintf(`{"level":"error","message":%q}`, "couldn't encode log line: "+err.Error()))
+	}
+	logOutputMu.Lock()
+	defer logOutputMu.Unlock()
+	logOutput.Write(append(encoded, '\n'))
+}
+
 // LogError logs non-fatal errors.
-func LogError(message string, fmtArgs ...interface{}) {
+func (g *Builder) LogError(message string, fmtArgs ...interface{}) {
+	g.recordError(fmt.Sprintf(message, fmtArgs...))
+	g.progressErrorEvent(fmt.Sprintf(message, fmtArgs...))
+	if logFormat == LogFormatJSON {
+		g.logJSON("error", fmt.Sprintf(message, fmtArgs...), nil)
+		return
+	}
 	spinner.Pause()
 	colorstring.Fprintf(os.Stderr, "\033[2K\r[red]error[reset] [bold][dim](%s)[reset] %s\n", currentWorkID, fmt.Sprintf(message, fmtArgs...))
 	spinner.Unpause()
 }
 
+// logPageError logs a non-fatal error that happened while building page.
+// Unlike LogError, the error is about the given page, not the builder's current one, which other goroutines change.
+func (g *Builder) logPageError(page ProgressDetails, message string, fmtArgs ...interface{}) {
+	message = fmt.Sprintf(message, fmtArgs...)
+	g.recordError(message)
+	g.progressPageErrorEvent(page, message)
+	if logFormat == LogFormatJSON {
+		g.logJSON("error", message, &page)
+		return
+	}
+	spinner.Pause()
+	colorstring.Fprintf(os.Stderr, "\033[2K\r[red]error[reset] [bold][dim](%s)[reset] %s\n", page.ObjectID, message)
+	spinner.Unpause()
+}
+
 // LogFatal logs fatal errors.
-func LogFatal(message string, fmtArgs ...interface{}) {
+func (g *Builder) LogFatal(message string, fmtArgs ...interface{}) {
+	g.recordError(fmt.Sprintf(message, fmtArgs...))
+	g.progressErrorEvent(fmt.Sprintf(message, fmtArgs...))
+	if logFormat == LogFormatJSON {
+		g.logJSON("fatal", fmt.Sprintf(message, fmtArgs...), nil)
+		return
+	}
 	spinner.Pause()
 	colorstring.Fprintf(os.Stderr, "\033[2K\r[invert][bold][red]crash[reset] [bold][dim](%s)[reset] %s\n", currentWorkID, fmt.Sprintf(message, fmtArgs...))
 	spinner.Unpause()
 }
 
 // LogInfo logs infos.
-func LogInfo(message string, fmtArgs ...interface{}) {
+func (g *Builder) LogInfo(message string, fmtArgs ...interface{}) {
+	if logFormat == LogFormatJSON {
+		g.logJSON("info", fmt.Sprintf(message, fmtArgs...), nil)
+		return
+	}
 	spinner.Pause()
 	colorstring.Fprintf(os.Stderr, "\033[2K\r[blue]info [reset] [bold][dim](%s)[reset] %s\n", currentWorkID, fmt.Sprintf(message, fmtArgs...))
 	spinner.Unpause()
 }
 
 var lastDebugTimestamp time.Time = time.Now()
+var lastDebugTimestampMu sync.Mutex
 
 // LogDebug logs debug messages.
-func LogDebug(message string, fmtArgs ...interface{}) {
+func (g *Builder) LogDebug(message string, fmtArgs ...interface{}) {
 	if os.Getenv("DEBUG") != "1" {
 		return
 	}
+	if logFormat == LogFormatJSON {
+		g.logJSON("debug", fmt.Sprintf(message, fmtArgs...), nil)
+		return
+	}
 	spinner.Pause()
+	lastDebugTimestampMu.Lock()
 	duration := time.Since(lastDebugTimestamp)
 	colorstring.Fprintf(os.Stderr, "\033[2K\r[magenta]debug[reset] [bold][dim](%s) %s[reset] %s\n", currentWorkID, duration.String(), fmt.Sprintf(message, fmtArgs...))
 	lastDebugTimestamp = time.Now()
+	lastDebugTimestampMu.Unlock()
 	spinner.Unpause()
 }
 
 // LogWarning logs warnings.
-func LogWarning(message string, fmtArgs ...interface{}) {
+func (g *Builder) LogWarning(message string, fmtArgs ...interface{}) {
+	g.recordWarning(fmt.Sprintf(message, fmtArgs...))
+	if logFormat == LogFormatJSON {
+		g.logJSON("warning", fmt.Sprintf(message, fmtArgs...), nil)
+		return
+	}
 	spinner.Pause()
 	colorstring.Fprintf(os.Stderr, "\033[2K\r[yellow]warn [reset] [bold][dim](%s)[reset] %s\n", currentWorkID, fmt.Sprintf(message, fmtArgs...))
 	spinner.Unpause()
@@ -178,10 +290,11 @@ func LogWarning(message string, fmtArgs ...interface{}) {
 // Line numbers are displayed on the left.
 func codeSpinnetAround(file string, lineNumber uint64, columnNumber uint64) string {
 	output := ""
+	lineIndex := int(lineNumber) - 1
 	for i, line := range strings.Split(file, "\n") {
-		if uint64(i) >= lineNumber-1-5 && uint64(i) <= lineNumber-1+5 {
+		if i >= lineIndex-5 && i <= lineIndex+5 {
 			line := fmt.Sprintf("%3d | %s\n", i+1, truncateLineAround(line, int(columnNumber)-1, 200))
-			if uint64(i) == lineNumber-1 {
+			if i == lineIndex {
 				line = "> " + line
 			} else {
 				line = "  " + line
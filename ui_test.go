@@ -0,0 +1,34 @@
+package ortfomk
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogJSON(t *testing.T) {
+	defer SetGlobalData(g)
+	defer func(output io.Writer) { logOutput = output }(logOutput)
+	defer SetLogFormat(logFormat)
+	output := bytes.Buffer{}
+	logOutput = &output
+	assert.Error(t, SetLogFormat("yaml"))
+	assert.NoError(t, SetLogFormat(LogFormatJSON))
+
+	SetGlobalData(&GlobalData{CurrentObjectID: "poster", CurrentLanguage: "fr", CurrentOutputFile: "dist/fr/poster.html"})
+	g.Progress.File = "src/:work.pug"
+	LogWarning("missing %s", "thumbnail")
+
+	var line LogLine
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &line))
+	assert.Equal(t, "warning", line.Level)
+	assert.Equal(t, "missing thumbnail", line.Message)
+	assert.Equal(t, "poster", line.ObjectID)
+	assert.Equal(t, "fr", line.Language)
+	assert.Equal(t, "src/:work.pug", line.Template)
+	assert.Equal(t, "dist/fr/poster.html", line.Output)
+	assert.False(t, line.Timestamp.IsZero())
+}
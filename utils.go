@@ -3,6 +3,7 @@ package ortfomk
 import (
 	"encoding/hex"
 	"errors"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -70,6 +71,30 @@ func StringsLooselyMatch(s1 string, sn ...string) bool {
 	return false
 }
 
+// writeFileAtomically writes content to a temporary file next to path, then renames it to path,
+// so that readers never see a partially-written file. Parent directories are created as needed.
+func writeFileAtomically(path string, content []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+		return err
+	}
+	temporary, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+	if _, err := temporary.Write(content); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temporary.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(temporary.Name(), path)
+}
+
 // func printfln(text string, a ...interface{}) {
 // 	fmt.Printf(text+"\n", a...)
 // }
@@ -164,6 +189,15 @@ outer:
 	return noDuplicates
 }
 
+func contains[T comparable](items []T, item T) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
 func excluding[T comparable](items []T, excluded ...T) []T {
 	withoutRemoved := make([]T, 0, len(items))
 outer:
@@ -1,6 +1,7 @@
 package ortfomk
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
@@ -17,7 +18,10 @@ import (
 // - Stops when gallery.pug is moved
 // - Updates references to a file when it is moved
 // - Warns when deleting a file that is depended upon
-func StartWatcher(db Database) {
+//
+// It blocks until ctx is cancelled, and then waits for the build in progress (if any) to finish.
+// failOn is used to compute the exit code of re-builds, see BuildReport.ExitCode.
+func (g *Builder) StartWatcher(ctx context.Context, db Database, failOn []string) {
 	watchPattern := regexp.MustCompile(`^.+\.(pug|mo)`)
 	//
 	// Content changes (new files or contents modified)
@@ -26,64 +30,88 @@ func StartWatcher(db Database) {
 	w.FilterOps(watcher.Create, watcher.Write, watcher.Move)
 	w.AddFilterHook(watcher.RegexFilterHook(watchPattern, false))
 
-	Status("Waiting for changes", ProgressDetails{})
+	g.Status("Waiting for changes", ProgressDetails{})
 	go func() {
+		select {
+		case <-ctx.Done():
+			w.Close()
+		case <-w.Closed:
+		}
+	}()
+
+	handlerDone := make(chan struct{})
+	go func() {
+		defer close(handlerDone)
 		for {
 			select {
 			case event := <-w.Event:
+				if ctx.Err() != nil {
+					continue
+				}
 				dependents := make([]string, 0)
 				if strings.HasSuffix(event.Path, ".pug") {
-					dependents = DependentsOf(g.TemplatesDirectory, event.Path, 10)
+					dependents = g.DependentsOf(g.TemplatesDirectory, event.Path, 10)
 				}
 				switch event.Op {
 				case watcher.Create:
 					fallthrough
 				case watcher.Write:
 					if strings.HasSuffix(event.Path, ".mo") {
-						LogInfo("Compiled translations changed: re-building everything")
-						translations, err := LoadTranslations()
-						SetTranslationsOnGlobalData(translations)
+						g.LogInfo("Compiled translations changed: re-building everything")
+						translations, loadErr := g.LoadTranslations()
+						if loadErr == nil {
+							g.Translations = translations
+						}
+						built, _, err := g.BuildAll(ctx, g.TemplatesDirectory, 0)
 						if err != nil {
-							LogError("Couldn't load the translation files: %s", err)
+							g.LogError("While re-building everything: %s", err)
 						}
-						BuildAll("src", 0)
+						// Logged after BuildAll, which resets the build report
+						if loadErr != nil {
+							g.LogError("Couldn't load the translation files, the previous ones were used: %s", loadErr)
+						}
+						g.BuildFinished(g.CurrentBuildReport().ExitCode(failOn))
+						g.TriggerLiveReload(built)
 					} else if strings.HasSuffix(event.Path, ".pug") {
-						LogInfo("Building file [bold]%s[/bold] and its dependents [bold]%s[/bold]", GetPathRelativeToSrcDir(event.Path), strings.Join(dependents, ", "))
+						g.LogInfo("Building file [bold]%s[/bold] and its dependents [bold]%s[/bold]", g.GetPathRelativeToSrcDir(event.Path), strings.Join(dependents, ", "))
+						built := make([]string, 0)
 						for _, filePath := range append(dependents, event.Path) {
 							if strings.Contains(filePath, ":work") {
-								BuildWorkPages(filePath)
+								built = append(built, g.BuildWorkPages(ctx, filePath)...)
 							} else if strings.Contains(filePath, ":tag") {
-								BuildTagPages(filePath)
+								built = append(built, g.BuildTagPages(ctx, filePath)...)
 							} else if strings.Contains(filePath, ":technology") {
-								BuildTechPages(filePath)
+								built = append(built, g.BuildTechPages(ctx, filePath)...)
 							} else {
-								BuildRegularPage(filePath)
+								built = append(built, g.BuildRegularPage(ctx, filePath)...)
 							}
 						}
-						for _, lang := range []string{"fr", "en"} {
+						g.saveBuildManifest()
+						g.TriggerLiveReload(built)
+						for _, lang := range g.Configuration.Languages {
 							g.Translations[lang].SavePO()
 						}
 					}
 				case watcher.Remove:
 					if len(dependents) > 0 {
-						LogWarning("Files %s depended on %s, which was removed", strings.Join(dependents, ", "), event.Path)
+						g.LogWarning("Files %s depended on %s, which was removed", strings.Join(dependents, ", "), event.Path)
 					}
 				case watcher.Rename:
-					if GetPathRelativeToSrcDir(event.OldPath) == "gallery.pug" {
-						LogWarning("gallery.pug was renamed, exiting: you'll need to update references to the filename in Go files.")
+					if g.GetPathRelativeToSrcDir(event.OldPath) == "gallery.pug" {
+						g.LogWarning("gallery.pug was renamed, exiting: you'll need to update references to the filename in Go files.")
 						w.Close()
 					}
-					LogDebug("%s -> %s, checking dependents %v", event.OldPath, event.Path, dependents)
+					g.LogDebug("%s -> %s, checking dependents %v", event.OldPath, event.Path, dependents)
 					if len(dependents) > 0 {
-						LogInfo("%s was renamed to %s: Updating references in %s", GetPathRelativeToSrcDir(event.OldPath), GetPathRelativeToSrcDir(event.Path), strings.Join(dependents, ", "))
+						g.LogInfo("%s was renamed to %s: Updating references in %s", g.GetPathRelativeToSrcDir(event.OldPath), g.GetPathRelativeToSrcDir(event.Path), strings.Join(dependents, ", "))
 						for _, filePath := range dependents {
-							UpdateExtendsStatement(filePath, event.OldPath, event.Path)
+							g.UpdateExtendsStatement(filePath, event.OldPath, event.Path)
 						}
 					}
 				}
 				fmt.Println("\r\033[K")
 			case err := <-w.Error:
-				LogError("An errror occured while watching changes in src/: %s", err)
+				g.LogError("An errror occured while watching changes in src/: %s", err)
 			case <-w.Closed:
 				return
 			}
@@ -91,41 +119,42 @@ func StartWatcher(db Database) {
 	}()
 
 	if err := w.AddRecursive(g.TemplatesDirectory); err != nil {
-		LogError("Couldn't add src/ to watcher: %s", err)
+		g.LogError("Couldn't add src/ to watcher: %s", err)
 	}
 
 	if err := w.AddRecursive("i18n"); err != nil {
-		LogError("Couldn't add i18n/ to watcher: %s", err)
+		g.LogError("Couldn't add i18n/ to watcher: %s", err)
 	}
 
 	if err := w.Start(100 * time.Millisecond); err != nil {
-		LogError("Couldn't start the watcher: %s", err)
+		g.LogError("Couldn't start the watcher: %s", err)
+		return
 	}
-
+	<-handlerDone
 }
 
 // UpdateExtendsStatement renames the file referenced by an extends statement
-func UpdateExtendsStatement(in string, from string, to string) {
+func (g *Builder) UpdateExtendsStatement(in string, from string, to string) {
 	extendsPattern := regexp.MustCompile(`(?m)^extends\s+(?:src/)?` + from + `(?:\.pug)?\s*$`)
 	file, err := os.Open(in)
 	if err != nil {
-		LogError(fmt.Sprintf("While updating the extends statement in %s from %s to %s: could not open file %s", in, from, to, in), err)
+		g.LogError(fmt.Sprintf("While updating the extends statement in %s from %s to %s: could not open file %s", in, from, to, in), err)
 	}
 	defer file.Close()
 	contents, err := os.ReadFile(in)
 	if err != nil {
-		LogError(fmt.Sprintf("While updating the extends statement in %s from %s to %s: could not read file %s", in, from, to, in), err)
+		g.LogError(fmt.Sprintf("While updating the extends statement in %s from %s to %s: could not read file %s", in, from, to, in), err)
 	}
 	_, err = file.Write(
 		extendsPattern.ReplaceAll(contents, []byte("extends "+to)),
 	)
 	if err != nil {
-		LogError(fmt.Sprintf("While updating the extends statement in %s from %s to %s: could not write to file %s", in, from, to, in), err)
+		g.LogError(fmt.Sprintf("While updating the extends statement in %s from %s to %s: could not write to file %s", in, from, to, in), err)
 	}
 }
 
 // GetPathRelativeToSrcDir takes an _absolute_ path and returns the part after (not containing) source
-func GetPathRelativeToSrcDir(absPath string) string {
+func (g *Builder) GetPathRelativeToSrcDir(absPath string) string {
 	relative, err := filepath.Rel(g.TemplatesDirectory, absPath)
 	if err != nil {
 		panic(err)
@@ -153,7 +182,7 @@ func Dependencies(content string) []string {
 // This function is recursive, dependents of dependents are also included.
 // The returned array is has the same order as the build order required to correctly update dependencies before their dependents
 // maxDepth is used to specify how deeply it should recurse (i.e. how many times it should call itself)
-func DependentsOf(searchIn string, pageFilepath string, maxDepth uint) (dependents []string) {
+func (g *Builder) DependentsOf(searchIn string, pageFilepath string, maxDepth uint) (dependents []string) {
 	err := filepath.WalkDir(searchIn, func(path string, dirEntry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -162,7 +191,7 @@ func DependentsOf(searchIn string, pageFilepath string, maxDepth uint) (dependen
 			content, err := os.ReadFile(path)
 
 			if err != nil {
-				LogError("Not checking for dependence on "+pageFilepath+": could not read file "+path, err)
+				g.LogError("Not checking for dependence on "+pageFilepath+": could not read file "+path, err)
 				return nil
 			}
 
@@ -171,9 +200,9 @@ func DependentsOf(searchIn string, pageFilepath string, maxDepth uint) (dependen
 			// add this file to the dependents
 			for _, dep := range Dependencies(string(content)) {
 				absoluteDep := filepath.Join(filepath.Dir(path), dep)
-				LogDebug("testing %s: %s == %s? checking with %s", path, dep, pageFilepath, absoluteDep)
+				g.LogDebug("testing %s: %s == %s? checking with %s", path, dep, pageFilepath, absoluteDep)
 				if err != nil {
-					LogError("while analyzing %s's dependencies: %s", pageFilepath, err)
+					g.LogError("while analyzing %s's dependencies: %s", pageFilepath, err)
 					continue
 				}
 
@@ -181,9 +210,9 @@ func DependentsOf(searchIn string, pageFilepath string, maxDepth uint) (dependen
 					dependents = append(dependents, path)
 					// Add dependents of dependent after (they need to be built _after_ the dependent because they themselves depend on the former)
 					if maxDepth > 1 {
-						dependents = append(dependents, DependentsOf(searchIn, path, maxDepth-1)...)
+						dependents = append(dependents, g.DependentsOf(searchIn, path, maxDepth-1)...)
 					} else {
-						LogWarning("While looking for dependents for %s: Maximum recursion depth reached, not recursing any further. You might have a circular dependency.", GetPathRelativeToSrcDir(pageFilepath))
+						g.LogWarning("While looking for dependents for %s: Maximum recursion depth reached, not recursing any further. You might have a circular dependency.", g.GetPathRelativeToSrcDir(pageFilepath))
 					}
 				}
 			}
@@ -192,7 +221,7 @@ func DependentsOf(searchIn string, pageFilepath string, maxDepth uint) (dependen
 	})
 
 	if err != nil {
-		LogError("While looking for dependents on "+GetPathRelativeToSrcDir(pageFilepath), err)
+		g.LogError("While looking for dependents on "+g.GetPathRelativeToSrcDir(pageFilepath), err)
 	}
 	return
 }
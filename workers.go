@@ -0,0 +1,89 @@
+package ortfomk
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// DefaultLinkCheckWorkers is how many links CheckDeadLinks checks at the same time, unless told otherwise.
+// It is kept low so that hosts linked to by many pages are not flooded with requests.
+const DefaultLinkCheckWorkers = 8
+
+// workersCount returns how many pages are built at the same time: Flags.Workers, or runtime.NumCPU() if it is 0 or less.
+func (g *Builder) workersCount() int {
+	if g.Flags.Workers > 0 {
+		return g.Flags.Workers
+	}
+	return runtime.NumCPU()
+}
+
+// pageWorkers limits how many pages of a Builder are built at the same time, across all templates.
+// It holds one token per page being built.
+type pageWorkers struct {
+	mu     sync.Mutex
+	tokens chan struct{}
+}
+
+// setPageWorkers sets how many pages are built at the same time. It must not be called while pages are being built.
+func (g *Builder) setPageWorkers(count int) {
+	g.pageWorkers.mu.Lock()
+	defer g.pageWorkers.mu.Unlock()
+	g.pageWorkers.tokens = make(chan struct{}, count)
+}
+
+// pageWorkerTokens returns the tokens of the page workers, with as many workers as workersCount() unless BuildAll set it.
+func (g *Builder) pageWorkerTokens() chan struct{} {
+	g.pageWorkers.mu.Lock()
+	defer g.pageWorkers.mu.Unlock()
+	if g.pageWorkers.tokens == nil {
+		g.pageWorkers.tokens = make(chan struct{}, g.workersCount())
+	}
+	return g.pageWorkers.tokens
+}
+
+// pageGroup builds the pages of a template in parallel, each in a page worker (see pageWorkers) and a JavaScript runtime of its own.
+type pageGroup struct {
+	builder          *Builder
+	ctx              context.Context
+	template         string
+	compiledTemplate []byte
+	wg               sync.WaitGroup
+	mu               sync.Mutex
+	built            []string
+}
+
+// newPageGroup creates a group to build pages of the template at path, compiled to compiledTemplate.
+func (g *Builder) newPageGroup(ctx context.Context, path string, compiledTemplate []byte) *pageGroup {
+	return &pageGroup{builder: g, ctx: ctx, template: path, compiledTemplate: compiledTemplate}
+}
+
+// Build starts building the page with the given hydration as soon as a page worker is available.
+// It returns false, without building the page, if the context is cancelled while waiting for a worker.
+func (p *pageGroup) Build(hydration *Hydration) bool {
+	g := p.builder
+	tokens := g.pageWorkerTokens()
+	select {
+	case tokens <- struct{}{}:
+	case <-p.ctx.Done():
+		return false
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer func() { <-tokens }()
+		javascriptRuntime := g.JSRuntimes().Get()
+		defer g.JSRuntimes().Put(javascriptRuntime)
+		built := g.BuildPage(p.ctx, javascriptRuntime, p.template, p.compiledTemplate, hydration)
+		p.mu.Lock()
+		p.built = append(p.built, built...)
+		p.mu.Unlock()
+	}()
+	return true
+}
+
+// Wait waits for every page started with Build to be built, and returns their output files.
+func (p *pageGroup) Wait() []string {
+	p.wg.Wait()
+	return p.built
+}